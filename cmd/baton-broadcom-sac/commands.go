@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/connector"
+	"github.com/conductorone/baton-sdk/pkg/cli"
+	"github.com/conductorone/baton-sdk/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// errConnectorReady stops the baton command once its connector is built, so a subcommand runs instead of a sync.
+var errConnectorReady = errors.New("baton-broadcom-sac: connector ready")
+
+// commandConnector returns a connector for a subcommand, configured the way the baton CLI configures a sync: the SDK
+// loads the flags, the BATON_ environment variables and the .baton.yaml config file, sets up logging and validates
+// the configuration. The SDK only does so when its command runs, so the command is run until it has a connector.
+func commandConnector(ctx context.Context, cmd *cobra.Command) (context.Context, *connector.Connector, error) {
+	var (
+		runCtx context.Context
+		c      *connector.Connector
+	)
+
+	batonCmd, err := cli.NewCmd(ctx, cmd.Root().Name(), &config{}, validateConfig, func(ctx context.Context, cfg *config) (types.ConnectorServer, error) {
+		var err error
+		c, err = newConnector(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		runCtx = ctx
+		return nil, errConnectorReady
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = batonCmd.RunE(cmd, nil)
+	if !errors.Is(err, errConnectorReady) {
+		if err == nil {
+			err = errors.New("baton-broadcom-sac: the connector was not created")
+		}
+		return nil, nil, err
+	}
+
+	return runCtx, c, nil
+}
@@ -3,11 +3,18 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"os"
+	"time"
 
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
 	"github.com/conductorone/baton-sdk/pkg/cli"
 	"github.com/spf13/cobra"
 )
 
+// defaultEnrichUsersConcurrency is the default number of user detail records fetched at once.
+const defaultEnrichUsersConcurrency = 4
+
 // config defines the external configuration required for the connector to run.
 type config struct {
 	cli.BaseConfig `mapstructure:",squash"` // Puts the base config options in the same place as the connector options
@@ -15,6 +22,20 @@ type config struct {
 	SacClientID     string `mapstructure:"sac-client-id"`
 	SacClientSecret string `mapstructure:"sac-client-secret"`
 	Tenant          string `mapstructure:"tenant"`
+	BaseURL         string `mapstructure:"base-url"`
+	AuthURL         string `mapstructure:"auth-url"`
+	PortalURL       string `mapstructure:"portal-url"`
+	PageSize        int    `mapstructure:"page-size"`
+
+	RetryMaxAttempts int           `mapstructure:"retry-max-attempts"`
+	RetryMaxElapsed  time.Duration `mapstructure:"retry-max-elapsed"`
+
+	CacheTTL      time.Duration `mapstructure:"cache-ttl"`
+	CacheMaxBytes int64         `mapstructure:"cache-max-bytes"`
+	CacheDir      string        `mapstructure:"cache-dir"`
+
+	EnrichUsers            bool `mapstructure:"enrich-users"`
+	EnrichUsersConcurrency int  `mapstructure:"enrich-users-concurrency"`
 }
 
 // validateConfig is run after the configuration is loaded, and should return an error if it isn't valid.
@@ -30,6 +51,78 @@ func validateConfig(ctx context.Context, cfg *config) error {
 	if cfg.Tenant == "" {
 		return fmt.Errorf("tenant name is missing")
 	}
+
+	if err := validateURL("base URL", cfg.BaseURL); err != nil {
+		return err
+	}
+
+	if err := validateURL("auth URL", cfg.AuthURL); err != nil {
+		return err
+	}
+
+	if err := validateURL("portal URL", cfg.PortalURL); err != nil {
+		return err
+	}
+
+	if cfg.PageSize < 1 {
+		return fmt.Errorf("page size must be at least 1")
+	}
+
+	if cfg.RetryMaxAttempts < 1 {
+		return fmt.Errorf("retry max attempts must be at least 1")
+	}
+
+	if cfg.RetryMaxElapsed < 0 {
+		return fmt.Errorf("retry max elapsed time must not be negative")
+	}
+
+	if cfg.CacheTTL < 0 {
+		return fmt.Errorf("cache TTL must not be negative")
+	}
+
+	if cfg.CacheMaxBytes < 0 {
+		return fmt.Errorf("cache max bytes must not be negative")
+	}
+
+	if cfg.EnrichUsersConcurrency < 1 {
+		return fmt.Errorf("enrich users concurrency must be at least 1")
+	}
+
+	if cfg.CacheDir != "" {
+		info, err := os.Stat(cfg.CacheDir)
+		if err != nil {
+			return fmt.Errorf("invalid cache directory: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("invalid cache directory: %s is not a directory", cfg.CacheDir)
+		}
+	}
+	return nil
+}
+
+// validateURL checks that an optional URL setting is an absolute http(s) URL.
+func validateURL(name, raw string) error {
+	if raw == "" {
+		return nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("invalid %s: scheme must be http or https", name)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("invalid %s: host is missing", name)
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid %s: query and fragment are not allowed", name)
+	}
+
 	return nil
 }
 
@@ -37,4 +130,25 @@ func cmdFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().String("sac-client-id", "", "Client ID for your Broadcom SAC instance. ($BATON_SAC_CLIENT_ID)")
 	cmd.PersistentFlags().String("sac-client-secret", "", "Client Secret for your Broadcom SAC instance. ($BATON_SAC_CLIENT_SECRET)")
 	cmd.PersistentFlags().String("tenant", "", "Name of your Broadcom SAC tenant. ($BATON_TENANT)")
+	cmd.PersistentFlags().String("base-url", "",
+		"Override the Broadcom SAC API root URL. Defaults to https://api.<tenant>.luminatesec.com. ($BATON_BASE_URL)")
+	cmd.PersistentFlags().String("auth-url", "",
+		"Override the Broadcom SAC OAuth token endpoint. Defaults to <base-url>/v1/oauth/token. ($BATON_AUTH_URL)")
+	cmd.PersistentFlags().String("portal-url", "",
+		"Override the Broadcom SAC admin portal URL resources link to. Defaults to https://<tenant>.admin.luminatesec.com. ($BATON_PORTAL_URL)")
+	cmd.PersistentFlags().Int("page-size", sac.DefaultPageSize, "Number of objects requested per Broadcom SAC API page. ($BATON_PAGE_SIZE)")
+	cmd.PersistentFlags().Int("retry-max-attempts", sac.DefaultRetryPolicy().MaxAttempts,
+		"Maximum number of attempts for a throttled or failed Broadcom SAC API request. ($BATON_RETRY_MAX_ATTEMPTS)")
+	cmd.PersistentFlags().Duration("retry-max-elapsed", sac.DefaultRetryPolicy().MaxElapsed,
+		"Maximum total time spent retrying a single Broadcom SAC API request. 0 disables the limit. ($BATON_RETRY_MAX_ELAPSED)")
+	cmd.PersistentFlags().Duration("cache-ttl", sac.DefaultCacheOptions().TTL,
+		"How long Broadcom SAC API responses are reused within a sync. 0 disables the cache. ($BATON_CACHE_TTL)")
+	cmd.PersistentFlags().Int64("cache-max-bytes", sac.DefaultCacheOptions().MaxBytes,
+		"Maximum size of the Broadcom SAC API responses cached in memory. 0 removes the limit. ($BATON_CACHE_MAX_BYTES)")
+	cmd.PersistentFlags().String("cache-dir", "",
+		"Directory cached responses evicted from memory are written to. Disabled when empty. ($BATON_CACHE_DIR)")
+	cmd.PersistentFlags().Bool("enrich-users", false,
+		"Fetch each user's detail record to add creation time, last login and MFA enrollment to the profile. ($BATON_ENRICH_USERS)")
+	cmd.PersistentFlags().Int("enrich-users-concurrency", defaultEnrichUsersConcurrency,
+		"Number of user detail records fetched at once when --enrich-users is set. ($BATON_ENRICH_USERS_CONCURRENCY)")
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// groupsCmd returns the groups command, which manages groups of the local identity provider.
+func groupsCmd(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "groups",
+		Short: "Manage groups of the Broadcom SAC local identity provider",
+	}
+
+	cmd.AddCommand(createGroupCmd(ctx), renameGroupCmd(ctx), deleteGroupCmd(ctx))
+
+	return cmd
+}
+
+func createGroupCmd(ctx context.Context) *cobra.Command {
+	var name string
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a group and print its ID",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, c, err := commandConnector(ctx, cmd)
+			if err != nil {
+				return err
+			}
+
+			gr, err := c.CreateLocalGroup(ctx, name)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), gr.Id.Resource)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Name of the group. (required)")
+	_ = cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func renameGroupCmd(ctx context.Context) *cobra.Command {
+	var name string
+
+	cmd := &cobra.Command{
+		Use:   "rename <group-id>",
+		Short: "Rename a group",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, c, err := commandConnector(ctx, cmd)
+			if err != nil {
+				return err
+			}
+
+			_, err = c.RenameLocalGroup(ctx, args[0], name)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "New name of the group. (required)")
+	_ = cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func deleteGroupCmd(ctx context.Context) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <group-id>",
+		Short: "Delete a group, keeping its members",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, c, err := commandConnector(ctx, cmd)
+			if err != nil {
+				return err
+			}
+
+			return c.DeleteLocalGroup(ctx, args[0])
+		},
+	}
+}
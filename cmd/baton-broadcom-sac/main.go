@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/conductorone/baton-broadcom-sac/pkg/connector"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
 	"github.com/conductorone/baton-sdk/pkg/cli"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/types"
@@ -27,6 +28,7 @@ func main() {
 
 	cmd.Version = version
 	cmdFlags(cmd)
+	cmd.AddCommand(usersCmd(ctx), groupsCmd(ctx))
 
 	err = cmd.Execute()
 	if err != nil {
@@ -38,7 +40,7 @@ func main() {
 func getConnector(ctx context.Context, cfg *config) (types.ConnectorServer, error) {
 	l := ctxzap.Extract(ctx)
 
-	cb, err := connector.New(ctx, cfg.SacClientID, cfg.SacClientSecret, cfg.Tenant)
+	cb, err := newConnector(ctx, cfg)
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
@@ -50,5 +52,35 @@ func getConnector(ctx context.Context, cfg *config) (types.ConnectorServer, erro
 		return nil, err
 	}
 
+	// A connector killed before it gets here leaves its spill directory behind; the next one to spill removes it.
+	go func() {
+		<-ctx.Done()
+		if err := cb.Close(); err != nil {
+			l.Warn("error removing the response cache spill directory", zap.Error(err))
+		}
+	}()
+
 	return c, nil
 }
+
+// newConnector returns the Broadcom SAC connector configured by cfg.
+func newConnector(ctx context.Context, cfg *config) (*connector.Connector, error) {
+	retryPolicy := sac.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = cfg.RetryMaxAttempts
+	retryPolicy.MaxElapsed = cfg.RetryMaxElapsed
+
+	enrichUsersConcurrency := 0
+	if cfg.EnrichUsers {
+		enrichUsersConcurrency = cfg.EnrichUsersConcurrency
+	}
+
+	return connector.New(ctx, cfg.SacClientID, cfg.SacClientSecret, cfg.Tenant, cfg.BaseURL, cfg.AuthURL, cfg.PortalURL, enrichUsersConcurrency,
+		sac.WithRetryPolicy(retryPolicy),
+		sac.WithPageSize(cfg.PageSize),
+		sac.WithCacheOptions(sac.CacheOptions{
+			TTL:      cfg.CacheTTL,
+			MaxBytes: cfg.CacheMaxBytes,
+			Dir:      cfg.CacheDir,
+		}),
+	)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/connector"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/spf13/cobra"
+)
+
+// usersCmd returns the users command, which manages users of the local identity provider directly, e.g. to
+// restore access when provisioning through ConductorOne is not an option.
+func usersCmd(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "Manage users of the Broadcom SAC local identity provider",
+	}
+
+	cmd.AddCommand(
+		createUserCmd(ctx),
+		userActionCmd(ctx, "block", "Block a user, who can no longer sign in", (*connector.Connector).BlockLocalUser),
+		userActionCmd(ctx, "unblock", "Unblock a user", (*connector.Connector).UnblockLocalUser),
+		userActionCmd(ctx, "delete", "Delete a user", (*connector.Connector).DeleteLocalUser),
+	)
+
+	return cmd
+}
+
+func createUserCmd(ctx context.Context) *cobra.Command {
+	var (
+		user     sac.LocalUser
+		groupIds []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user and print its ID",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, c, err := commandConnector(ctx, cmd)
+			if err != nil {
+				return err
+			}
+
+			ur, err := c.CreateLocalUser(ctx, user, groupIds)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), ur.Id.Resource)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&user.Username, "username", "", "Username of the user. (required)")
+	cmd.Flags().StringVar(&user.FirstName, "first-name", "", "First name of the user.")
+	cmd.Flags().StringVar(&user.LastName, "last-name", "", "Last name of the user.")
+	cmd.Flags().StringVar(&user.Email, "email", "", "Email address of the user. (required)")
+	cmd.Flags().StringVar(&user.NotificationEmail, "notification-email", "", "Address notifications are sent to. Defaults to --email.")
+	cmd.Flags().StringSliceVar(&groupIds, "group", nil, "ID of a local group the user joins. Can be repeated.")
+	_ = cmd.MarkFlagRequired("username")
+	_ = cmd.MarkFlagRequired("email")
+
+	return cmd
+}
+
+func userActionCmd(ctx context.Context, use string, short string, action func(c *connector.Connector, ctx context.Context, userId string) error) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " <user-id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, c, err := commandConnector(ctx, cmd)
+			if err != nil {
+				return err
+			}
+
+			return action(c, ctx, args[0])
+		},
+	}
+}
@@ -2,46 +2,73 @@ package connector
 
 import (
 	"context"
-	"fmt"
+	"sort"
 
 	sac "github.com/conductorone/baton-broadcom-sac/pkg/sac"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
-	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
-	grant "github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
 )
 
-const (
-	viewer = "viewer"
-	admin  = "admin"
-)
-
-var roles = []string{
-	viewer,
-	admin,
-}
-
 type accountBuilder struct {
 	resourceType *v2.ResourceType
 	client       *sac.Client
 	tenant       string
+	portalURL    string
 }
 
 func (a *accountBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 	return a.resourceType
 }
 
-func accountResource(tenant string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
-	ret, err := rs.NewResource(
-		tenant,
+func accountResource(tenant string, settings *sac.TenantSettings, identityProviderCount int, portalURL string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	// SAC does not report whether an identity provider is enabled, so every configured one is counted.
+	profile := map[string]interface{}{
+		"tenant":                             tenant,
+		"configured_identity_provider_count": identityProviderCount,
+	}
+
+	displayName := tenant
+	if settings != nil {
+		displayName = valOrFallback(settings.DisplayName, valOrFallback(settings.Name, tenant))
+		profile["tenant_name"] = displayName
+		profile["region"] = settings.Region
+		profile["license_type"] = settings.LicenseType
+
+		var features []string
+		for feature, enabled := range settings.Features {
+			if enabled {
+				features = append(features, feature)
+			}
+		}
+		sort.Strings(features)
+
+		enabledFeatures := make([]interface{}, 0, len(features))
+		for _, feature := range features {
+			enabledFeatures = append(enabledFeatures, feature)
+		}
+		profile["enabled_features"] = enabledFeatures
+	}
+
+	appTraitOptions := []rs.AppTraitOption{rs.WithAppProfile(profile)}
+
+	ret, err := rs.NewAppResource(
+		displayName,
 		accountResourceType,
 		tenant,
+		appTraitOptions,
+		rs.WithParentResourceID(parentResourceID),
+		withPortalLink(portalURL),
 		rs.WithAnnotation(
-			&v2.ChildResourceType{ResourceTypeId: userResourceType.Id},
-			&v2.ChildResourceType{ResourceTypeId: groupResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: identityProviderResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: roleResourceType.Id},
 			&v2.ChildResourceType{ResourceTypeId: policyResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: collectionResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: siteResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: applicationResourceType.Id},
 		),
 	)
 	if err != nil {
@@ -52,62 +79,51 @@ func accountResource(tenant string, parentResourceID *v2.ResourceId) (*v2.Resour
 }
 
 func (a *accountBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	// The account is the root of the resource tree, so listing it marks the start of a new sync.
+	a.client.ResetCache(ctx)
+
+	// Reading tenant settings needs a broader scope than syncing does, so the account is still synced without them.
+	var settings *sac.TenantSettings
+	tenantSettings, err := a.client.GetTenantSettings(ctx)
+	switch {
+	case err == nil:
+		settings = &tenantSettings
+	case sac.IsForbidden(err) || sac.IsNotFound(err):
+		ctxzap.Extract(ctx).Warn("baton-broadcom-sac: unable to read tenant settings", zap.Error(err))
+	default:
+		return nil, "", nil, wrapError(err, "failed to get tenant settings")
+	}
+
+	identityProviders, err := a.client.ListIdentityProviders(ctx)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list identity providers")
+	}
+
 	var rv []*v2.Resource
-	ur, err := accountResource(a.tenant, parentResourceID)
+	ur, err := accountResource(a.tenant, settings, len(identityProviders), a.portalURL, parentResourceID)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
 	rv = append(rv, ur)
 
-	return rv, "", nil, nil
+	return rv, "", rateLimitAnnotations(a.client), nil
 }
 
-func (a *accountBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
-	var rv []*v2.Entitlement
-
-	for _, role := range roles {
-		permissionOptions := []ent.EntitlementOption{
-			ent.WithGrantableTo(userResourceType),
-			ent.WithDescription(fmt.Sprintf("%s user role", role)),
-			ent.WithDisplayName(fmt.Sprintf("%s role %s", resource.DisplayName, role)),
-		}
-		en := ent.NewPermissionEntitlement(resource, role, permissionOptions...)
-		rv = append(rv, en)
-	}
-
-	return rv, "", nil, nil
+// Entitlements returns nothing: administrative access to the tenant is modeled by the role resources.
+func (a *accountBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
 }
 
-func (a *accountBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	var rv []*v2.Grant
-	users, err := a.client.ListAllUsers(ctx)
-	if err != nil {
-		return rv, "", nil, err
-	}
-
-	for _, user := range users {
-		userCopy := user
-		ur, err := userResource(&userCopy, resource.Id)
-		if err != nil {
-			return nil, "", nil, err
-		}
-
-		entitlement := viewer
-		if user.IsAdmin {
-			entitlement = admin
-		}
-
-		roleGrant := grant.NewGrant(resource, entitlement, ur.Id)
-		rv = append(rv, roleGrant)
-	}
-
-	return rv, "", nil, nil
+func (a *accountBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
 }
 
-func newAccountBuilder(client *sac.Client) *accountBuilder {
+func newAccountBuilder(client *sac.Client, tenant string, portalURL string) *accountBuilder {
 	return &accountBuilder{
 		resourceType: accountResourceType,
 		client:       client,
+		tenant:       tenant,
+		portalURL:    portalURL,
 	}
 }
@@ -0,0 +1,30 @@
+package connector
+
+import (
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestAccountProfileCountsConfiguredIdentityProviders(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Update(func(f *sactest.Fixtures) {
+		f.IdentityProviders = append(f.IdentityProviders, sac.IdentityProvider{ID: "ldap-idp", Name: "LDAP", Provider: "LDAP"})
+	})
+	a := newAccountBuilder(srv.NewClient(), "acme", "")
+
+	accounts, _ := listAll(t, a, nil)
+	if len(accounts) != 1 {
+		t.Fatalf("got %d accounts, want 1", len(accounts))
+	}
+
+	trait, err := rs.GetAppTrait(accounts[0])
+	if err != nil {
+		t.Fatalf("reading the app trait: %v", err)
+	}
+	if got, _ := rs.GetProfileInt64Value(trait.Profile, "configured_identity_provider_count"); got != 3 {
+		t.Errorf("got %d configured identity providers, want 3", got)
+	}
+}
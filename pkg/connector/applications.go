@@ -0,0 +1,148 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const accessEntitlement = "access"
+
+type applicationBuilder struct {
+	resourceType *v2.ResourceType
+	client       *sac.Client
+	portalURL    string
+}
+
+func (a *applicationBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return a.resourceType
+}
+
+func applicationResource(application *sac.Application, portalURL string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"application_id":   application.ID,
+		"application_name": application.Name,
+		"application_type": application.Type,
+		"enabled":          application.Enabled,
+		"site_id":          application.SiteID,
+		"internal_address": application.ConnectionSettings.InternalAddress,
+		"external_address": application.ConnectionSettings.ExternalAddress,
+	}
+
+	if application.ConnectionSettings.LuminateAddress != "" {
+		profile["luminate_address"] = application.ConnectionSettings.LuminateAddress
+	}
+
+	appTraitOptions := []rs.AppTraitOption{rs.WithAppProfile(profile)}
+
+	resourceOptions := []rs.ResourceOption{
+		rs.WithParentResourceID(parentResourceID),
+		withPortalLink(portalURL, "applications", application.ID),
+	}
+	if application.Description != "" {
+		resourceOptions = append(resourceOptions, rs.WithDescription(application.Description))
+	}
+
+	ret, err := rs.NewAppResource(
+		application.Name,
+		applicationResourceType,
+		application.ID,
+		appTraitOptions,
+		resourceOptions...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// applicationParentResourceID returns the ID of the resource an application is listed under: its site, else its
+// collection, else the account. collections maps object IDs to the ID of their collection, as collectionOf does.
+func applicationParentResourceID(application *sac.Application, collections map[string]string, accountID *v2.ResourceId) *v2.ResourceId {
+	switch {
+	case application.SiteID != "":
+		return &v2.ResourceId{ResourceType: siteResourceType.Id, Resource: application.SiteID}
+	case collections[application.ID] != "":
+		return &v2.ResourceId{ResourceType: collectionResourceType.Id, Resource: collections[application.ID]}
+	default:
+		return accountID
+	}
+}
+
+// List returns the applications listed under a site, a collection or the account in a single page. Every parent
+// is listed from the same application pages, which the client's per-sync cache downloads once per sync.
+func (a *applicationBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	applications, err := a.client.ListAllApplications(ctx)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list applications")
+	}
+
+	var collections map[string]string
+	if parentResourceID.ResourceType != siteResourceType.Id {
+		collections, err = collectionOf(ctx, a.client)
+		if err != nil {
+			return nil, "", nil, wrapError(err, "failed to list collections")
+		}
+	}
+
+	var accountID *v2.ResourceId
+	if parentResourceID.ResourceType == accountResourceType.Id {
+		accountID = parentResourceID
+	}
+
+	var rv []*v2.Resource
+	for _, application := range applications {
+		applicationCopy := application
+		parent := applicationParentResourceID(&applicationCopy, collections, accountID)
+		if parent == nil || parent.ResourceType != parentResourceID.ResourceType || parent.Resource != parentResourceID.Resource {
+			continue
+		}
+
+		ar, err := applicationResource(&applicationCopy, a.portalURL, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		rv = append(rv, ar)
+	}
+
+	return rv, "", rateLimitAnnotations(a.client), nil
+}
+
+func (a *applicationBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	var rv []*v2.Entitlement
+
+	accessOptions := []ent.EntitlementOption{
+		ent.WithGrantableTo(userResourceType, groupResourceType, policyResourceType),
+		ent.WithDescription(fmt.Sprintf("Access to %s application", resource.DisplayName)),
+		ent.WithDisplayName(fmt.Sprintf("%s %s", resource.DisplayName, accessEntitlement)),
+	}
+
+	en := ent.NewPermissionEntitlement(resource, accessEntitlement, accessOptions...)
+	rv = append(rv, en)
+
+	return rv, "", nil, nil
+}
+
+// Grants returns nothing: access to an application is granted by the policies targeting it, see policyBuilder.Grants.
+func (a *applicationBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func newApplicationBuilder(client *sac.Client, portalURL string) *applicationBuilder {
+	return &applicationBuilder{
+		resourceType: applicationResourceType,
+		client:       client,
+		portalURL:    portalURL,
+	}
+}
@@ -0,0 +1,48 @@
+package connector
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+)
+
+func TestApplicationListPagesApplicationsOncePerSync(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Update(func(f *sactest.Fixtures) {
+		f.Sites = append(f.Sites, sac.Site{ID: "site-dc2", Name: "DC2"})
+		f.Applications = append(f.Applications,
+			sac.Application{ID: "app-db", Name: "Database", SiteID: "site-dc2"},
+			sac.Application{ID: "app-saas", Name: "SaaS"},
+			sac.Application{ID: "app-portal", Name: "Portal"},
+		)
+		f.CollectionObjects["collection-production"] = append(f.CollectionObjects["collection-production"],
+			sac.CollectionObject{ID: "app-portal", Name: "Portal", Type: "Application"})
+	})
+	client := srv.NewClient(sac.WithPageSize(1))
+	a := newApplicationBuilder(client, "")
+
+	want := map[*v2.ResourceId]string{
+		{ResourceType: siteResourceType.Id, Resource: "site-dc1"}:                    "app-ssh,app-web",
+		{ResourceType: siteResourceType.Id, Resource: "site-dc2"}:                    "app-db",
+		{ResourceType: collectionResourceType.Id, Resource: "collection-production"}: "app-portal",
+		accountID(): "app-saas",
+	}
+	for parent, ids := range want {
+		resources, calls := listAll(t, a, parent)
+		if got := strings.Join(resourceIDs(resources), ","); got != ids {
+			t.Errorf("got applications %q under %s, want %q", got, parent.Resource, ids)
+		}
+		if calls != 1 {
+			t.Errorf("listed applications under %s in %d calls, want 1", parent.Resource, calls)
+		}
+	}
+
+	// The five pages of one application are downloaded once for all parents.
+	if got := srv.Requests(http.MethodGet, "/v2/applications"); got != 5 {
+		t.Errorf("got %d application list requests, want 5", got)
+	}
+}
@@ -0,0 +1,242 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+type collectionBuilder struct {
+	resourceType *v2.ResourceType
+	client       *sac.Client
+	portalURL    string
+}
+
+func (c *collectionBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return c.resourceType
+}
+
+func collectionResource(collection *sac.Collection, objects []sac.CollectionObject, roles []sac.Role, portalURL string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	var applications, sites, policies []interface{}
+	for _, object := range objects {
+		switch object.Type {
+		case collectionApplication:
+			applications = append(applications, object.ID)
+		case collectionSite:
+			sites = append(sites, object.ID)
+		case collectionPolicy:
+			policies = append(policies, object.ID)
+		}
+	}
+
+	profile := map[string]interface{}{
+		"collection_id":   collection.ID,
+		"collection_name": collection.Name,
+		"parent_id":       collection.ParentID,
+		"applications":    applications,
+		"sites":           sites,
+		"policies":        policies,
+	}
+
+	// The names of the roles that can be bound on the collection, keyed by role ID. Entitlements are built from them.
+	collectionRoles := make(map[string]interface{})
+	for _, role := range roles {
+		if role.Type != tenantRoleType {
+			collectionRoles[role.ID] = role.Name
+		}
+	}
+	profile["roles"] = collectionRoles
+
+	appTraitOptions := []rs.AppTraitOption{rs.WithAppProfile(profile)}
+
+	resourceOptions := []rs.ResourceOption{
+		rs.WithParentResourceID(parentResourceID),
+		withPortalLink(portalURL, "collections", collection.ID),
+		rs.WithAnnotation(
+			&v2.ChildResourceType{ResourceTypeId: siteResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: applicationResourceType.Id},
+		),
+	}
+	if collection.Description != "" {
+		resourceOptions = append(resourceOptions, rs.WithDescription(collection.Description))
+	}
+
+	ret, err := rs.NewAppResource(
+		collection.Name,
+		collectionResourceType,
+		collection.ID,
+		appTraitOptions,
+		resourceOptions...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (c *collectionBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	pageNumber, err := parsePageNumber(pToken.Token)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	collections, paginationData, err := c.client.ListCollections(ctx, pageNumber)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list collections")
+	}
+
+	token, err := nextPageNumberToken(pageNumber, len(collections), paginationData)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	roles, err := c.client.ListRoles(ctx)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list roles")
+	}
+
+	var rv []*v2.Resource
+	for _, collection := range collections {
+		collectionCopy := collection
+		objects, err := c.client.ListAllCollectionObjects(ctx, collection.ID)
+		if err != nil {
+			return nil, "", nil, wrapError(err, "failed to list collection objects")
+		}
+
+		cr, err := collectionResource(&collectionCopy, objects, roles, c.portalURL, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		rv = append(rv, cr)
+	}
+
+	return rv, token, rateLimitAnnotations(c.client), nil
+}
+
+// Entitlements returns one entitlement per collection role, held by the users and groups bound to the role on the collection.
+// The roles are read from the collection profile.
+func (c *collectionBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	appTrait, err := rs.GetAppTrait(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	roles := appTrait.Profile.GetFields()["roles"].GetStructValue().GetFields()
+	roleIds := make([]string, 0, len(roles))
+	for roleId := range roles {
+		roleIds = append(roleIds, roleId)
+	}
+	sort.Strings(roleIds)
+
+	var rv []*v2.Entitlement
+	for _, roleId := range roleIds {
+		roleName := roles[roleId].GetStringValue()
+
+		permissionOptions := []ent.EntitlementOption{
+			ent.WithGrantableTo(userResourceType, groupResourceType),
+			ent.WithDescription(fmt.Sprintf("%s on %s collection", roleName, resource.DisplayName)),
+			ent.WithDisplayName(fmt.Sprintf("%s Collection %s", resource.DisplayName, roleName)),
+		}
+		en := ent.NewPermissionEntitlement(resource, roleId, permissionOptions...)
+		rv = append(rv, en)
+	}
+
+	return rv, "", nil, nil
+}
+
+// Grants returns the role bindings on the collection, as grants of the entitlement named after the bound role.
+func (c *collectionBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	pageNumber, err := parsePageNumber(pToken.Token)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	bindings, paginationData, err := c.client.ListRoleBindings(ctx, pageNumber)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list role bindings")
+	}
+
+	token, err := nextPageNumberToken(pageNumber, len(bindings), paginationData)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var rv []*v2.Grant
+	for _, binding := range bindings {
+		if binding.CollectionID != resource.Id.Resource {
+			continue
+		}
+
+		bindingCopy := binding
+		grant, err := directoryEntityGrant(resource, binding.RoleID, &bindingCopy.Entity)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if grant != nil {
+			rv = append(rv, grant)
+		}
+	}
+
+	return rv, token, rateLimitAnnotations(c.client), nil
+}
+
+func newCollectionBuilder(client *sac.Client, portalURL string) *collectionBuilder {
+	return &collectionBuilder{
+		resourceType: collectionResourceType,
+		client:       client,
+		portalURL:    portalURL,
+	}
+}
+
+// Types of the objects a collection holds.
+const (
+	collectionApplication = "Application"
+	collectionSite        = "Site"
+	collectionPolicy      = "Policy"
+)
+
+// collectionOf returns the collection each application, site and policy is listed under, keyed by object ID.
+// An object in several collections is listed under the first one, in the order SAC returns collections.
+// Responses are served from the client's per-sync cache after the first call.
+func collectionOf(ctx context.Context, client *sac.Client) (map[string]string, error) {
+	collections, err := client.ListAllCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := make(map[string]string)
+	for _, collection := range collections {
+		objects, err := client.ListAllCollectionObjects(ctx, collection.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range objects {
+			if _, ok := rv[object.ID]; !ok {
+				rv[object.ID] = collection.ID
+			}
+		}
+	}
+
+	return rv, nil
+}
+
+// inCollection reports whether an object listed under collectionID, empty for none, belongs under parentResourceID.
+func inCollection(parentResourceID *v2.ResourceId, collectionID string) bool {
+	if parentResourceID.ResourceType == collectionResourceType.Id {
+		return parentResourceID.Resource == collectionID
+	}
+	return collectionID == ""
+}
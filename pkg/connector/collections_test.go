@@ -0,0 +1,42 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+)
+
+func TestCollectionEntitlementsComeFromTheProfile(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Update(func(f *sactest.Fixtures) {
+		f.Roles = append(f.Roles, sac.Role{ID: "role-collection-viewer", Name: "Collection Viewer", Type: "collection"})
+	})
+	c := newCollectionBuilder(srv.NewClient(), "")
+
+	collections, _ := listAll(t, c, accountID())
+	if len(collections) != 1 {
+		t.Fatalf("got collections %v, want collection-production", resourceIDs(collections))
+	}
+
+	// Entitlements are built without calling the API.
+	srv.Close()
+	entitlements, _, annos, err := c.Entitlements(context.Background(), collections[0], nil)
+	if err != nil {
+		t.Fatalf("listing entitlements: %v", err)
+	}
+	if annos != nil {
+		t.Errorf("got annotations %v, want none", annos)
+	}
+
+	if len(entitlements) != 2 {
+		t.Fatalf("got %d entitlements, want one per collection role", len(entitlements))
+	}
+	if got := entitlements[0].Id; got != "collection:collection-production:role-collection-admin" {
+		t.Errorf("got entitlement %s, want the collection admin entitlement first", got)
+	}
+	if got := entitlements[1].DisplayName; got != "Production Collection Collection Viewer" {
+		t.Errorf("got display name %q", got)
+	}
+}
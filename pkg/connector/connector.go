@@ -2,7 +2,6 @@ package connector
 
 import (
 	"context"
-	"fmt"
 	"io"
 
 	sac "github.com/conductorone/baton-broadcom-sac/pkg/sac"
@@ -14,19 +13,26 @@ import (
 )
 
 type Connector struct {
-	client       *sac.Client
-	clientID     string
-	clientSecret string
-	tenant       string
+	client    *sac.Client
+	tenant    string
+	portalURL string
+	// enrichUsersConcurrency is how many user detail records are fetched at once. Zero disables user enrichment.
+	enrichUsersConcurrency int
 }
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced from the upstream service.
 func (c *Connector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
 	return []connectorbuilder.ResourceSyncer{
-		newAccountBuilder(c.client),
-		newUserBuilder(c.client),
-		newGroupBuilder(c.client),
-		newPolicyBuilder(c.client),
+		newAccountBuilder(c.client, c.tenant, c.portalURL),
+		newIdentityProviderBuilder(c.client, c.portalURL),
+		newUserBuilder(c.client, c.portalURL, c.enrichUsersConcurrency),
+		newGroupBuilder(c.client, c.portalURL),
+		newRoleBuilder(c.client, c.portalURL),
+		newPolicyBuilder(c.client, c.portalURL),
+		newCollectionBuilder(c.client, c.portalURL),
+		newSiteBuilder(c.client, c.portalURL),
+		newConnectorBuilder(c.client, c.portalURL),
+		newApplicationBuilder(c.client, c.portalURL),
 	}
 }
 
@@ -40,41 +46,56 @@ func (c *Connector) Asset(ctx context.Context, asset *v2.AssetRef) (string, io.R
 func (c *Connector) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error) {
 	return &v2.ConnectorMetadata{
 		DisplayName: "Broadcom SAC",
-		Description: "Connector syncing users and groups from Broadcom SAC.",
+		Description: "Connector syncing users, groups, policies and applications from Broadcom SAC.",
 	}, nil
 }
 
-// Validate is called to ensure that the connector is properly configured. It should exercise any API credentials
-// to be sure that they are valid.
+// Validate is called to ensure that the connector is properly configured. It lists the identity providers, bypassing
+// the response cache, so the credentials are exercised through the same token source and retry policy as a sync.
 func (c *Connector) Validate(ctx context.Context) (annotations.Annotations, error) {
-	token, err := sac.CreateBearerToken(ctx, c.clientID, c.clientSecret, c.tenant)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get access token: %w", err)
-	}
-
-	if token == "" {
-		return nil, fmt.Errorf("missing access token")
+	if _, err := c.client.ListIdentityProviders(sac.WithoutCache(ctx)); err != nil {
+		return nil, wrapError(err, "failed to validate credentials")
 	}
 
 	return nil, nil
 }
 
-// New returns a new instance of the connector.
-func New(ctx context.Context, clientID, clientSecret, tenant string) (*Connector, error) {
+// Close removes the responses the client spilled to disk.
+func (c *Connector) Close() error {
+	return c.client.Close()
+}
+
+// New returns a new instance of the connector. An empty baseURL defaults to the tenant's API root,
+// an empty authURL to the token endpoint under baseURL and an empty portalURL to the tenant's admin portal.
+// When enrichUsersConcurrency is positive, user profiles are enriched from their detail records, fetching that many at once.
+func New(ctx context.Context, clientID, clientSecret, tenant, baseURL, authURL, portalURL string, enrichUsersConcurrency int, opts ...sac.ClientOption) (*Connector, error) {
 	httpClient, err := uhttp.NewClient(ctx, uhttp.WithLogger(true, ctxzap.Extract(ctx)))
 	if err != nil {
 		return nil, err
 	}
 
-	token, err := sac.CreateBearerToken(ctx, clientID, clientSecret, tenant)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get access token: %w", err)
+	if baseURL == "" {
+		baseURL = sac.DefaultBaseURL(tenant)
+	}
+
+	if authURL == "" {
+		authURL = sac.TokenURL(baseURL)
+	}
+
+	if portalURL == "" {
+		portalURL = sac.DefaultPortalURL(tenant)
+	}
+
+	tokenSource := sac.NewTokenSource(httpClient, authURL, clientID, clientSecret)
+	if _, err := tokenSource.Token(ctx); err != nil {
+		return nil, wrapError(err, "failed to get access token")
 	}
 
 	return &Connector{
-		client:       sac.NewClient(httpClient, tenant, token),
-		clientID:     clientID,
-		clientSecret: clientSecret,
-		tenant:       tenant,
+		client:    sac.NewClient(httpClient, baseURL, tokenSource, opts...),
+		tenant:    tenant,
+		portalURL: portalURL,
+
+		enrichUsersConcurrency: enrichUsersConcurrency,
 	}, nil
 }
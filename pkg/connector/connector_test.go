@@ -0,0 +1,144 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"google.golang.org/grpc/codes"
+)
+
+func newTestConnector(t *testing.T, srv *sactest.Server) *Connector {
+	t.Helper()
+
+	c, err := New(context.Background(), sactest.ClientID, sactest.ClientSecret, "acme", srv.URL, "", srv.URL, 0)
+	if err != nil {
+		t.Fatalf("creating connector: %v", err)
+	}
+	return c
+}
+
+func TestValidateUsesTheClientTokenSource(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	c := newTestConnector(t, srv)
+
+	if _, err := c.Validate(ctx); err != nil {
+		t.Fatalf("validating: %v", err)
+	}
+
+	// The token issued when the connector was created is reused, and refreshed once it is rejected.
+	srv.ExpireTokens()
+
+	if _, err := c.Validate(ctx); err != nil {
+		t.Fatalf("validating after the token expired: %v", err)
+	}
+	if got := srv.Requests(http.MethodPost, "/v1/oauth/token"); got != 2 {
+		t.Errorf("got %d token requests, want 2", got)
+	}
+	if got := srv.Requests(http.MethodGet, "/v2/identities/settings/identity-providers"); got != 3 {
+		t.Errorf("got %d identity provider requests, want 3", got)
+	}
+}
+
+func TestValidateRejectsRevokedCredentials(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	c := newTestConnector(t, srv)
+
+	srv.Inject(sactest.Fault{Path: "/v1/oauth/token", Status: http.StatusUnauthorized})
+	srv.ExpireTokens()
+
+	_, err := c.Validate(ctx)
+	requireCode(t, err, codes.Unauthenticated)
+}
+
+func TestListReportsRateLimit(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	p := newPolicyBuilder(srv.NewClient(), "")
+
+	_, _, annos, err := p.List(ctx, &v2.ResourceId{ResourceType: accountResourceType.Id, Resource: "acme"}, &pagination.Token{})
+	if err != nil {
+		t.Fatalf("listing policies: %v", err)
+	}
+	if len(annos) != 0 {
+		t.Fatalf("got annotations %v without rate limit headers, want none", annos)
+	}
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "100")
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("X-RateLimit-Reset", "60")
+	srv.Inject(sactest.Fault{Path: "/v2/policies", Status: http.StatusOK, Times: 1, Header: header, Body: `{"content":[],"last":true}`})
+
+	_, _, annos, err = p.List(ctx, &v2.ResourceId{ResourceType: accountResourceType.Id, Resource: "acme"}, &pagination.Token{Token: "1"})
+	if err != nil {
+		t.Fatalf("listing policies: %v", err)
+	}
+
+	desc := &v2.RateLimitDescription{}
+	ok, err := annos.Pick(desc)
+	if err != nil || !ok {
+		t.Fatalf("got annotations %v, want a rate limit description", annos)
+	}
+	if desc.Status != v2.RateLimitDescription_STATUS_OVERLIMIT || desc.Limit != 100 || desc.ResetAt == nil {
+		t.Errorf("got rate limit %v, want an exhausted limit of 100 with a reset time", desc)
+	}
+}
+
+func TestResourcesLinkToThePortal(t *testing.T) {
+	srv := newTestServer(t)
+	client := srv.NewClient()
+	const portalURL = "https://acme.admin.luminatesec.com/"
+
+	site := &v2.ResourceId{ResourceType: siteResourceType.Id, Resource: "site-dc1"}
+	collection := &v2.ResourceId{ResourceType: collectionResourceType.Id, Resource: "collection-production"}
+	tests := []struct {
+		builder connectorbuilder.ResourceSyncer
+		parent  *v2.ResourceId
+		want    string
+	}{
+		{newAccountBuilder(client, "acme", portalURL), nil, "https://acme.admin.luminatesec.com/"},
+		{newIdentityProviderBuilder(client, portalURL), accountID(), "https://acme.admin.luminatesec.com/identities/local-idp"},
+		{newUserBuilder(client, portalURL, 0), identityProviderID(sactest.LocalIdentityProviderID), "https://acme.admin.luminatesec.com/identities/local-idp/users/user-alice"},
+		{newGroupBuilder(client, portalURL), identityProviderID(sactest.LocalIdentityProviderID), "https://acme.admin.luminatesec.com/identities/local-idp/groups/group-admins"},
+		{newPolicyBuilder(client, portalURL), accountID(), "https://acme.admin.luminatesec.com/policies/policy-ssh"},
+		{newRoleBuilder(client, portalURL), accountID(), "https://acme.admin.luminatesec.com/roles/role-tenant-admin"},
+		{newCollectionBuilder(client, portalURL), accountID(), "https://acme.admin.luminatesec.com/collections/collection-production"},
+		{newSiteBuilder(client, portalURL), collection, "https://acme.admin.luminatesec.com/sites/site-dc1"},
+		{newConnectorBuilder(client, portalURL), site, "https://acme.admin.luminatesec.com/sites/site-dc1/connectors/connector-dc1-a"},
+		{newApplicationBuilder(client, portalURL), site, "https://acme.admin.luminatesec.com/applications/app-ssh"},
+	}
+
+	for _, tt := range tests {
+		resourceType := tt.builder.ResourceType(context.Background()).Id
+		resources, _ := listAll(t, tt.builder, tt.parent)
+		if len(resources) == 0 {
+			t.Errorf("listed no %s resources", resourceType)
+			continue
+		}
+
+		link := &v2.ExternalLink{}
+		annos := annotations.Annotations(resources[0].Annotations)
+		if ok, err := annos.Pick(link); err != nil || !ok {
+			t.Errorf("got no portal link for %s %s", resourceType, resources[0].Id.Resource)
+			continue
+		}
+		if link.Url != tt.want {
+			t.Errorf("got portal link %s for %s, want %s", link.Url, resourceType, tt.want)
+		}
+	}
+
+	// Without a portal URL, resources are not linked.
+	resources, _ := listAll(t, newPolicyBuilder(client, ""), accountID())
+	annos := annotations.Annotations(resources[0].Annotations)
+	if ok, _ := annos.Pick(&v2.ExternalLink{}); ok {
+		t.Error("got a portal link without a portal URL")
+	}
+}
@@ -0,0 +1,105 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+type connectorBuilder struct {
+	resourceType *v2.ResourceType
+	client       *sac.Client
+	portalURL    string
+}
+
+func (c *connectorBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return c.resourceType
+}
+
+func connectorResource(connector *sac.Connector, portalURL string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"connector_id":    connector.ID,
+		"connector_name":  connector.Name,
+		"site_id":         connector.SiteID,
+		"enabled":         connector.Enabled,
+		"status":          connector.Status,
+		"version":         connector.Version,
+		"deployment_type": connector.DeploymentType,
+	}
+
+	if lastSeen, ok := parseTimestamp(connector.LastSeen); ok {
+		profile["last_seen"] = lastSeen.Format(time.RFC3339)
+	}
+
+	appTraitOptions := []rs.AppTraitOption{rs.WithAppProfile(profile)}
+
+	ret, err := rs.NewAppResource(
+		connector.Name,
+		connectorResourceType,
+		connector.ID,
+		appTraitOptions,
+		rs.WithParentResourceID(parentResourceID),
+		rs.WithDescription(fmt.Sprintf("%s connector", connector.DeploymentType)),
+		withPortalLink(portalURL, "sites", connector.SiteID, "connectors", connector.ID),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (c *connectorBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil || parentResourceID.ResourceType != siteResourceType.Id {
+		return nil, "", nil, nil
+	}
+
+	pageNumber, err := parsePageNumber(pToken.Token)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	connectors, paginationData, err := c.client.ListSiteConnectors(ctx, parentResourceID.Resource, pageNumber)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list site connectors")
+	}
+
+	token, err := nextPageNumberToken(pageNumber, len(connectors), paginationData)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var rv []*v2.Resource
+	for _, connector := range connectors {
+		connectorCopy := connector
+		cr, err := connectorResource(&connectorCopy, c.portalURL, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		rv = append(rv, cr)
+	}
+
+	return rv, token, rateLimitAnnotations(c.client), nil
+}
+
+func (c *connectorBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func (c *connectorBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func newConnectorBuilder(client *sac.Client, portalURL string) *connectorBuilder {
+	return &connectorBuilder{
+		resourceType: connectorResourceType,
+		client:       client,
+		portalURL:    portalURL,
+	}
+}
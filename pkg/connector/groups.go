@@ -3,6 +3,7 @@ package connector
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
@@ -10,6 +11,10 @@ import (
 	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
 )
@@ -17,6 +22,7 @@ import (
 type groupBuilder struct {
 	resourceType *v2.ResourceType
 	client       *sac.Client
+	portalURL    string
 }
 
 const memberEntitlement = "member"
@@ -48,11 +54,13 @@ func baseGroupResource(entity *sac.DirectoryEntity, parentResourceID *v2.Resourc
 	return ret, nil
 }
 
-func groupResource(group *sac.Group, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+func groupResource(group *sac.Group, portalURL string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
 	profile := map[string]interface{}{
-		"group_id":    group.ID,
-		"group_name":  group.Name,
-		"provider_id": group.IdentityProviderID,
+		"group_id":               group.ID,
+		"group_name":             group.Name,
+		"provider_id":            group.IdentityProviderID,
+		"identity_provider":      group.RepositoryType,
+		"identifier_in_provider": valOrFallback(group.IdentifierInProvider, group.ID),
 	}
 
 	groupTraitOptions := []rs.GroupTraitOption{rs.WithGroupProfile(profile)}
@@ -63,6 +71,7 @@ func groupResource(group *sac.Group, parentResourceID *v2.ResourceId) (*v2.Resou
 		group.ID,
 		groupTraitOptions,
 		rs.WithParentResourceID(parentResourceID),
+		withPortalLink(portalURL, "identities", group.IdentityProviderID, "groups", group.ID),
 	)
 	if err != nil {
 		return nil, err
@@ -71,26 +80,35 @@ func groupResource(group *sac.Group, parentResourceID *v2.ResourceId) (*v2.Resou
 	return ret, nil
 }
 
-func (g *groupBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+func (g *groupBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
 	if parentResourceID == nil {
 		return nil, "", nil, nil
 	}
 
-	groups, err := g.client.ListAllGroups(ctx)
+	if parentResourceID.ResourceType != identityProviderResourceType.Id {
+		return nil, "", nil, nil
+	}
+
+	groups, paginationData, err := g.client.ListGroupsPerProvider(ctx, parentResourceID.Resource, pToken.Token)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("failed to list groups: %w", err)
+		return nil, "", nil, wrapError(err, "failed to list groups")
+	}
+
+	token, err := nextOffsetPageToken(pToken.Token, paginationData)
+	if err != nil {
+		return nil, "", nil, err
 	}
 
 	var rv []*v2.Resource
 	for _, group := range groups {
 		groupCopy := group
-		gr, err := groupResource(&groupCopy, parentResourceID)
+		gr, err := groupResource(&groupCopy, g.portalURL, parentResourceID)
 		if err != nil {
 			return nil, "", nil, err
 		}
 		rv = append(rv, gr)
 	}
-	return rv, "", nil, nil
+	return rv, token, rateLimitAnnotations(g.client), nil
 }
 
 func (g *groupBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
@@ -109,8 +127,6 @@ func (g *groupBuilder) Entitlements(_ context.Context, resource *v2.Resource, _
 }
 
 func (g *groupBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	var token string
-
 	bag, err := parsePageToken(pToken.Token, resource.Id)
 	if err != nil {
 		return nil, "", nil, err
@@ -127,21 +143,24 @@ func (g *groupBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken
 	}
 
 	members, paginationData, err := g.client.ListGroupMembers(ctx, identityProviderId, resource.Id.Resource, bag.PageToken())
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list group members")
+	}
+
+	nextPage, err := nextOffsetPageToken(bag.PageToken(), paginationData)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	if !paginationData.Last {
-		token, err = bag.NextToken(paginationData.NextPage)
-		if err != nil {
-			return nil, "", nil, err
-		}
+	token, err := bag.NextToken(nextPage)
+	if err != nil {
+		return nil, "", nil, err
 	}
 
 	var rv []*v2.Grant
 	for _, member := range members {
 		memberCopy := member
-		ur, err := userResource(&memberCopy, resource.Id)
+		ur, err := userResource(&memberCopy, nil, g.portalURL, resource.Id)
 		if err != nil {
 			return nil, "", nil, err
 		}
@@ -150,12 +169,259 @@ func (g *groupBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken
 		rv = append(rv, grant)
 	}
 
-	return rv, token, nil, nil
+	return rv, token, rateLimitAnnotations(g.client), nil
+}
+
+// Grant adds a user to a group. Only groups of the local identity provider can be changed; membership of groups
+// synced from external identity providers is managed in the identity provider.
+func (g *groupBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	ctx = sac.WithoutCache(ctx)
+
+	if principal.Id.ResourceType != userResourceType.Id {
+		return nil, status.Errorf(codes.InvalidArgument, "baton-broadcom-sac: only users can be granted group membership, got %s", principal.Id.ResourceType)
+	}
+
+	identityProviderId, err := g.localIdentityProvider(ctx, entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkUserIdentityProvider(principal, identityProviderId); err != nil {
+		return nil, err
+	}
+
+	err = g.client.AddGroupMember(ctx, identityProviderId, entitlement.Resource.Id.Resource, principal.Id.Resource)
+	if err != nil {
+		if sac.IsConflict(err) {
+			ctxzap.Extract(ctx).Info("baton-broadcom-sac: user is already a member of the group",
+				zap.String("group_id", entitlement.Resource.Id.Resource),
+				zap.String("user_id", principal.Id.Resource),
+			)
+			return nil, nil
+		}
+		return nil, wrapError(err, "failed to add group member")
+	}
+
+	return rateLimitAnnotations(g.client), nil
+}
+
+// Revoke removes a user from a group of the local identity provider.
+func (g *groupBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	ctx = sac.WithoutCache(ctx)
+
+	principal := grant.Principal
+	entitlement := grant.Entitlement
+
+	if principal.Id.ResourceType != userResourceType.Id {
+		return nil, status.Errorf(codes.InvalidArgument, "baton-broadcom-sac: only users can be revoked group membership, got %s", principal.Id.ResourceType)
+	}
+
+	identityProviderId, err := g.localIdentityProvider(ctx, entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.client.RemoveGroupMember(ctx, identityProviderId, entitlement.Resource.Id.Resource, principal.Id.Resource)
+	if err != nil {
+		if sac.IsNotFound(err) {
+			ctxzap.Extract(ctx).Info("baton-broadcom-sac: user is not a member of the group",
+				zap.String("group_id", entitlement.Resource.Id.Resource),
+				zap.String("user_id", principal.Id.Resource),
+			)
+			return nil, nil
+		}
+		return nil, wrapError(err, "failed to remove group member")
+	}
+
+	return rateLimitAnnotations(g.client), nil
+}
+
+// localIdentityProvider returns the identity provider of a group, failing with codes.Unimplemented when it is not
+// the local identity provider.
+func (g *groupBuilder) localIdentityProvider(ctx context.Context, group *v2.Resource) (string, error) {
+	groupTrait, err := rs.GetGroupTrait(group)
+	if err != nil {
+		return "", err
+	}
+
+	identityProviderId, ok := rs.GetProfileStringValue(groupTrait.Profile, "provider_id")
+	if !ok {
+		return "", fmt.Errorf("error fetching provider_id from group profile")
+	}
+
+	identityProviders, err := g.client.ListIdentityProviders(ctx)
+	if err != nil {
+		return "", wrapError(err, "failed to list identity providers")
+	}
+
+	for _, identityProvider := range identityProviders {
+		if identityProvider.ID != identityProviderId {
+			continue
+		}
+
+		if !identityProvider.IsLocal() {
+			return "", status.Errorf(codes.Unimplemented,
+				"baton-broadcom-sac: membership of the %s group is managed in the %s identity provider and cannot be changed through SAC",
+				group.DisplayName, identityProvider.Name)
+		}
+
+		return identityProviderId, nil
+	}
+
+	return "", status.Errorf(codes.NotFound, "baton-broadcom-sac: identity provider %s of group %s not found", identityProviderId, group.DisplayName)
+}
+
+// createLocalGroup creates a group in the local identity provider. Group names are compared case-insensitively
+// with the existing groups, so no two groups share a name.
+func (g *groupBuilder) createLocalGroup(ctx context.Context, name string) (*v2.Resource, error) {
+	ctx = sac.WithoutCache(ctx)
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "baton-broadcom-sac: a name is required to create a group")
+	}
+
+	identityProvider, err := findLocalIdentityProvider(ctx, g.client)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := g.client.GroupsPager(identityProvider.ID, 0).All(ctx)
+	if err != nil {
+		return nil, wrapError(err, "failed to list groups")
+	}
+
+	if err := checkGroupName(groups, "", name); err != nil {
+		return nil, err
+	}
+
+	created, err := g.client.CreateLocalGroup(ctx, identityProvider.ID, name)
+	if err != nil {
+		return nil, wrapError(err, "failed to create group")
+	}
+
+	return groupResource(&created, g.portalURL, &v2.ResourceId{ResourceType: identityProviderResourceType.Id, Resource: identityProvider.ID})
+}
+
+// renameLocalGroup renames a group of the local identity provider, unless another group already has the name.
+func (g *groupBuilder) renameLocalGroup(ctx context.Context, groupId string, name string) (*v2.Resource, error) {
+	ctx = sac.WithoutCache(ctx)
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "baton-broadcom-sac: a name is required to rename a group")
+	}
+
+	identityProvider, err := findLocalIdentityProvider(ctx, g.client)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := g.client.GroupsPager(identityProvider.ID, 0).All(ctx)
+	if err != nil {
+		return nil, wrapError(err, "failed to list groups")
+	}
+
+	group, ok := findGroup(groups, groupId)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "baton-broadcom-sac: group %s not found in the %s identity provider", groupId, identityProvider.Name)
+	}
+
+	if err := checkGroupName(groups, groupId, name); err != nil {
+		return nil, err
+	}
+
+	if group.Name != name {
+		if err := g.client.RenameGroup(ctx, identityProvider.ID, groupId, name); err != nil {
+			return nil, wrapError(err, "failed to rename group")
+		}
+		group.Name = name
+	}
+
+	return groupResource(&group, g.portalURL, &v2.ResourceId{ResourceType: identityProviderResourceType.Id, Resource: identityProvider.ID})
+}
+
+// deleteLocalGroup deletes a group of the local identity provider.
+func (g *groupBuilder) deleteLocalGroup(ctx context.Context, groupId string) error {
+	ctx = sac.WithoutCache(ctx)
+
+	identityProvider, err := findLocalIdentityProvider(ctx, g.client)
+	if err != nil {
+		return err
+	}
+
+	if err := g.client.DeleteGroup(ctx, identityProvider.ID, groupId); err != nil {
+		return wrapError(err, "failed to delete group")
+	}
+
+	return nil
+}
+
+// Create creates a group in the local identity provider named after the resource's display name.
+func (g *groupBuilder) Create(ctx context.Context, resource *v2.Resource) (*v2.Resource, annotations.Annotations, error) {
+	created, err := g.createLocalGroup(ctx, resource.DisplayName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return created, rateLimitAnnotations(g.client), nil
+}
+
+// Delete deletes a group of the local identity provider.
+func (g *groupBuilder) Delete(ctx context.Context, resourceId *v2.ResourceId) (annotations.Annotations, error) {
+	if err := g.deleteLocalGroup(ctx, resourceId.Resource); err != nil {
+		return nil, err
+	}
+
+	return rateLimitAnnotations(g.client), nil
+}
+
+// checkGroupName fails with codes.AlreadyExists when a group other than groupId is named name.
+func checkGroupName(groups []sac.Group, groupId string, name string) error {
+	for _, group := range groups {
+		if group.ID != groupId && strings.EqualFold(group.Name, name) {
+			return status.Errorf(codes.AlreadyExists, "baton-broadcom-sac: a group named %s already exists: %s", group.Name, group.ID)
+		}
+	}
+	return nil
+}
+
+func hasGroup(groups []sac.Group, groupId string) bool {
+	_, ok := findGroup(groups, groupId)
+	return ok
+}
+
+func findGroup(groups []sac.Group, groupId string) (sac.Group, bool) {
+	for _, group := range groups {
+		if group.ID == groupId {
+			return group, true
+		}
+	}
+	return sac.Group{}, false
+}
+
+// checkUserIdentityProvider fails with codes.FailedPrecondition when the user belongs to another identity provider
+// than identityProviderId. Users whose profile does not record their identity provider are let through.
+func checkUserIdentityProvider(user *v2.Resource, identityProviderId string) error {
+	userTrait, err := rs.GetUserTrait(user)
+	if err != nil {
+		return nil
+	}
+
+	userIdentityProviderId, ok := rs.GetProfileStringValue(userTrait.Profile, "identity_provider_id")
+	if !ok || userIdentityProviderId == identityProviderId {
+		return nil
+	}
+
+	return status.Errorf(codes.FailedPrecondition,
+		"baton-broadcom-sac: user %s belongs to identity provider %s and cannot join a group of identity provider %s",
+		user.DisplayName, userIdentityProviderId, identityProviderId)
 }
 
-func newGroupBuilder(client *sac.Client) *groupBuilder {
+func newGroupBuilder(client *sac.Client, portalURL string) *groupBuilder {
 	return &groupBuilder{
 		resourceType: groupResourceType,
 		client:       client,
+		portalURL:    portalURL,
 	}
 }
@@ -0,0 +1,237 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"google.golang.org/grpc/codes"
+)
+
+func TestGroupListPagesEachIdentityProvider(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Update(func(f *sactest.Fixtures) {
+		f.Groups[sactest.LocalIdentityProviderID] = append(f.Groups[sactest.LocalIdentityProviderID],
+			sac.Group{ID: "group-ops", Name: "Ops", RepositoryType: "local", IdentityProviderID: sactest.LocalIdentityProviderID})
+	})
+	g := newGroupBuilder(srv.NewClient(sac.WithPageSize(1)), "")
+
+	local, calls := listAll(t, g, identityProviderID(sactest.LocalIdentityProviderID))
+	if got := strings.Join(resourceIDs(local), ","); got != "group-admins,group-ops" {
+		t.Errorf("got local groups %s, want group-admins,group-ops", got)
+	}
+	if calls != 2 {
+		t.Errorf("listed local groups in %d calls, want one per page", calls)
+	}
+
+	okta, _ := listAll(t, g, identityProviderID(sactest.OktaIdentityProviderID))
+	if got := strings.Join(resourceIDs(okta), ","); got != "group-engineering" {
+		t.Errorf("got Okta groups %s, want group-engineering", got)
+	}
+}
+
+func TestGroupGrantAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	g := newGroupBuilder(srv.NewClient(), "")
+
+	admins := testGroup(t, "group-admins")
+	bob := testUser(t, "user-bob")
+	member := testEntitlement(t, g, admins)
+
+	if _, err := g.Grant(ctx, bob, member); err != nil {
+		t.Fatalf("granting: %v", err)
+	}
+	if !contains(groupMembers(srv, "group-admins"), "user-bob") {
+		t.Fatal("bob is not a member after the grant")
+	}
+
+	// Granting an existing membership succeeds without changing it.
+	if _, err := g.Grant(ctx, bob, member); err != nil {
+		t.Fatalf("granting again: %v", err)
+	}
+
+	if _, err := g.Revoke(ctx, &v2.Grant{Entitlement: member, Principal: bob}); err != nil {
+		t.Fatalf("revoking: %v", err)
+	}
+	if contains(groupMembers(srv, "group-admins"), "user-bob") {
+		t.Fatal("bob is still a member after the revoke")
+	}
+
+	if _, err := g.Revoke(ctx, &v2.Grant{Entitlement: member, Principal: bob}); err != nil {
+		t.Fatalf("revoking again: %v", err)
+	}
+}
+
+func TestGroupGrantRejectsExternalGroups(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	g := newGroupBuilder(srv.NewClient(), "")
+
+	engineering := testGroup(t, "group-engineering")
+	member := testEntitlement(t, g, engineering)
+
+	_, err := g.Grant(ctx, testUser(t, "user-carol"), member)
+	requireCode(t, err, codes.Unimplemented)
+
+	_, err = g.Revoke(ctx, &v2.Grant{Entitlement: member, Principal: testUser(t, "user-carol")})
+	requireCode(t, err, codes.Unimplemented)
+}
+
+func TestGroupGrantRejectsUsersOfOtherIdentityProviders(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	g := newGroupBuilder(srv.NewClient(), "")
+
+	admins := testGroup(t, "group-admins")
+
+	_, err := g.Grant(ctx, testUser(t, "user-carol"), testEntitlement(t, g, admins))
+	requireCode(t, err, codes.FailedPrecondition)
+	if contains(groupMembers(srv, "group-admins"), "user-carol") {
+		t.Fatal("carol was added to the group")
+	}
+}
+
+// localGroup returns a group of the local identity provider as stored by the server.
+func localGroup(srv *sactest.Server, groupId string) (sac.Group, bool) {
+	var (
+		rv sac.Group
+		ok bool
+	)
+	srv.Update(func(f *sactest.Fixtures) {
+		rv, ok = findGroup(f.Groups[sactest.LocalIdentityProviderID], groupId)
+	})
+	return rv, ok
+}
+
+func TestGroupCreateRenameAndDelete(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	g := newGroupBuilder(srv.NewClient(), "")
+
+	created, _, err := g.Create(ctx, &v2.Resource{DisplayName: "  Ops  "})
+	if err != nil {
+		t.Fatalf("creating: %v", err)
+	}
+	if created.DisplayName != "Ops" {
+		t.Errorf("got display name %q, want Ops", created.DisplayName)
+	}
+	if got := created.ParentResourceId.GetResource(); got != sactest.LocalIdentityProviderID {
+		t.Errorf("got parent %q, want the local identity provider", got)
+	}
+	groupId := created.Id.Resource
+	if group, ok := localGroup(srv, groupId); !ok || group.Name != "Ops" {
+		t.Fatalf("got group %+v, want Ops in the local identity provider", group)
+	}
+
+	renamed, err := g.renameLocalGroup(ctx, groupId, "Operations")
+	if err != nil {
+		t.Fatalf("renaming: %v", err)
+	}
+	if renamed.DisplayName != "Operations" {
+		t.Errorf("got display name %q, want Operations", renamed.DisplayName)
+	}
+	if group, _ := localGroup(srv, groupId); group.Name != "Operations" {
+		t.Fatalf("got group name %q, want Operations", group.Name)
+	}
+
+	if _, err := g.Delete(ctx, created.Id); err != nil {
+		t.Fatalf("deleting: %v", err)
+	}
+	if _, ok := localGroup(srv, groupId); ok {
+		t.Fatal("the group still exists after the delete")
+	}
+}
+
+func TestGroupNamesAreUnique(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	g := newGroupBuilder(srv.NewClient(), "")
+
+	_, err := g.createLocalGroup(ctx, "ADMINS")
+	requireCode(t, err, codes.AlreadyExists)
+
+	_, err = g.createLocalGroup(ctx, " ")
+	requireCode(t, err, codes.InvalidArgument)
+
+	created, err := g.createLocalGroup(ctx, "Ops")
+	if err != nil {
+		t.Fatalf("creating: %v", err)
+	}
+
+	_, err = g.renameLocalGroup(ctx, created.Id.Resource, "admins")
+	requireCode(t, err, codes.AlreadyExists)
+
+	_, err = g.renameLocalGroup(ctx, created.Id.Resource, "")
+	requireCode(t, err, codes.InvalidArgument)
+
+	_, err = g.renameLocalGroup(ctx, "group-missing", "Missing")
+	requireCode(t, err, codes.NotFound)
+
+	if group, _ := localGroup(srv, created.Id.Resource); group.Name != "Ops" {
+		t.Fatalf("got group name %q, want Ops", group.Name)
+	}
+}
+
+func TestGroupRenameToItsOwnNameSkipsTheUpdate(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	g := newGroupBuilder(srv.NewClient(), "")
+
+	renamed, err := g.renameLocalGroup(ctx, "group-admins", "Admins")
+	if err != nil {
+		t.Fatalf("renaming: %v", err)
+	}
+	if renamed.DisplayName != "Admins" {
+		t.Errorf("got display name %q, want Admins", renamed.DisplayName)
+	}
+	if got := srv.Requests(http.MethodPut, "/v2/identities/"+sactest.LocalIdentityProviderID+"/groups/group-admins"); got != 0 {
+		t.Errorf("got %d rename requests, want 0", got)
+	}
+
+	// A group may change the case of its own name.
+	if _, err := g.renameLocalGroup(ctx, "group-admins", "ADMINS"); err != nil {
+		t.Fatalf("renaming to another case: %v", err)
+	}
+	if group, _ := localGroup(srv, "group-admins"); group.Name != "ADMINS" {
+		t.Fatalf("got group name %q, want ADMINS", group.Name)
+	}
+	if got := srv.Requests(http.MethodPut, "/v2/identities/"+sactest.LocalIdentityProviderID+"/groups/group-admins"); got != 1 {
+		t.Errorf("got %d rename requests, want 1", got)
+	}
+}
+
+func TestGroupLifecycleIsLimitedToTheLocalIdentityProvider(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	g := newGroupBuilder(srv.NewClient(), "")
+
+	// Groups of external identity providers are not found among the local groups.
+	_, err := g.renameLocalGroup(ctx, "group-engineering", "Platform")
+	requireCode(t, err, codes.NotFound)
+
+	_, err = g.Delete(ctx, &v2.ResourceId{ResourceType: groupResourceType.Id, Resource: "group-engineering"})
+	requireCode(t, err, codes.NotFound)
+
+	srv.Update(func(f *sactest.Fixtures) {
+		for i, identityProvider := range f.IdentityProviders {
+			if identityProvider.ID == sactest.LocalIdentityProviderID {
+				f.IdentityProviders = append(f.IdentityProviders[:i:i], f.IdentityProviders[i+1:]...)
+				break
+			}
+		}
+	})
+
+	_, err = g.createLocalGroup(ctx, "Ops")
+	requireCode(t, err, codes.FailedPrecondition)
+
+	_, err = g.renameLocalGroup(ctx, "group-admins", "Operators")
+	requireCode(t, err, codes.FailedPrecondition)
+
+	_, err = g.Delete(ctx, &v2.ResourceId{ResourceType: groupResourceType.Id, Resource: "group-admins"})
+	requireCode(t, err, codes.FailedPrecondition)
+}
@@ -1,8 +1,24 @@
 package connector
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	grant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func parsePageToken(token string, resourceID *v2.ResourceId) (*pagination.Bag, error) {
@@ -22,9 +38,242 @@ func parsePageToken(token string, resourceID *v2.ResourceId) (*pagination.Bag, e
 	return b, nil
 }
 
+// nextOffsetPageToken returns the token of the offset-paginated page following the page fetched with token.
+func nextOffsetPageToken(token string, paginationData sac.PaginationData) (string, error) {
+	if paginationData.Last {
+		return "", nil
+	}
+
+	if paginationData.NextPage != "" && paginationData.NextPage == token {
+		return "", fmt.Errorf("%w: page %q requested twice", sac.ErrPaginationLoop, token)
+	}
+
+	return paginationData.NextPage, nil
+}
+
+// parsePageNumber parses a page token produced by nextPageNumberToken. An empty token is the first page.
+func parsePageNumber(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	pageNumber, err := strconv.Atoi(token)
+	if err != nil || pageNumber < 0 {
+		return 0, fmt.Errorf("invalid page token %q", token)
+	}
+
+	return pageNumber, nil
+}
+
+// nextPageNumberToken returns the token for the page following a page-number paginated page holding itemCount items,
+// or an empty token on the last page.
+func nextPageNumberToken(pageNumber int, itemCount int, paginationData sac.PaginationData) (string, error) {
+	if paginationData.Last || itemCount == 0 {
+		return "", nil
+	}
+
+	next := paginationData.Number + 1
+	if next <= pageNumber {
+		return "", fmt.Errorf("%w: page %d requested twice", sac.ErrPaginationLoop, next)
+	}
+
+	return strconv.Itoa(next), nil
+}
+
+// directoryEntityResource returns the user or group a directory entity refers to, or nil for other kinds of entities.
+func directoryEntityResource(entity *sac.DirectoryEntity, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	switch entity.Type {
+	case user:
+		return baseUserResource(entity, parentResourceID)
+	case group:
+		return baseGroupResource(entity, parentResourceID)
+	default:
+		return nil, nil
+	}
+}
+
+// principalDirectoryEntity returns the directory entity policies are assigned and roles bound to for a user or
+// group synced by the connector.
+func principalDirectoryEntity(principal *v2.Resource) (sac.DirectoryEntity, error) {
+	switch principal.Id.ResourceType {
+	case userResourceType.Id:
+		userTrait, err := rs.GetUserTrait(principal)
+		if err != nil {
+			return sac.DirectoryEntity{}, err
+		}
+
+		identityProviderId, ok := rs.GetProfileStringValue(userTrait.Profile, "identity_provider_id")
+		if !ok {
+			return sac.DirectoryEntity{}, fmt.Errorf("error fetching identity_provider_id from user profile")
+		}
+
+		identityProviderType, _ := rs.GetProfileStringValue(userTrait.Profile, "identity_provider")
+		identifierInProvider, _ := rs.GetProfileStringValue(userTrait.Profile, "identifier_in_provider")
+		firstName, _ := rs.GetProfileStringValue(userTrait.Profile, "first_name")
+		lastName, _ := rs.GetProfileStringValue(userTrait.Profile, "last_name")
+
+		return sac.DirectoryEntity{
+			ID:                   principal.Id.Resource,
+			IdentifierInProvider: valOrFallback(identifierInProvider, principal.Id.Resource),
+			IdentityProviderID:   identityProviderId,
+			IdentityProviderType: identityProviderType,
+			Type:                 user,
+			DisplayName:          strings.TrimSpace(firstName + " " + lastName),
+		}, nil
+
+	case groupResourceType.Id:
+		groupTrait, err := rs.GetGroupTrait(principal)
+		if err != nil {
+			return sac.DirectoryEntity{}, err
+		}
+
+		identityProviderId, ok := rs.GetProfileStringValue(groupTrait.Profile, "provider_id")
+		if !ok {
+			return sac.DirectoryEntity{}, fmt.Errorf("error fetching provider_id from group profile")
+		}
+
+		identityProviderType, _ := rs.GetProfileStringValue(groupTrait.Profile, "identity_provider")
+		identifierInProvider, _ := rs.GetProfileStringValue(groupTrait.Profile, "identifier_in_provider")
+
+		return sac.DirectoryEntity{
+			ID:                   principal.Id.Resource,
+			IdentifierInProvider: valOrFallback(identifierInProvider, principal.Id.Resource),
+			IdentityProviderID:   identityProviderId,
+			IdentityProviderType: identityProviderType,
+			Type:                 group,
+			DisplayName:          principal.DisplayName,
+		}, nil
+
+	default:
+		return sac.DirectoryEntity{}, status.Errorf(codes.InvalidArgument, "baton-broadcom-sac: only users and groups can be granted access, got %s", principal.Id.ResourceType)
+	}
+}
+
+// directoryEntityGrant grants the entitlement of resource to the user or group a directory entity refers to.
+// Grants to groups expand to the group members. It returns nil for other kinds of entities.
+func directoryEntityGrant(resource *v2.Resource, entitlementName string, entity *sac.DirectoryEntity) (*v2.Grant, error) {
+	principal, err := directoryEntityResource(entity, resource.Id)
+	if err != nil {
+		return nil, err
+	}
+	if principal == nil {
+		return nil, nil
+	}
+
+	var grantOptions []grant.GrantOption
+	if principal.Id.ResourceType == groupResourceType.Id {
+		grantOptions = append(grantOptions, grant.WithAnnotation(&v2.GrantExpandable{
+			EntitlementIds: []string{ent.NewEntitlementID(principal, memberEntitlement)},
+		}))
+	}
+
+	return grant.NewGrant(resource, entitlementName, principal.Id, grantOptions...), nil
+}
+
+// withPortalLink links a resource to its page in the SAC admin portal. It does nothing when no portal URL is set.
+func withPortalLink(portalURL string, elem ...string) rs.ResourceOption {
+	return func(r *v2.Resource) error {
+		if portalURL == "" {
+			return nil
+		}
+
+		link := portalURL
+		if len(elem) > 0 {
+			var err error
+			link, err = url.JoinPath(portalURL, elem...)
+			if err != nil {
+				return fmt.Errorf("invalid portal URL %q: %w", portalURL, err)
+			}
+		}
+
+		return rs.WithAnnotation(&v2.ExternalLink{Url: link})(r)
+	}
+}
+
+// parseTimestamp parses an RFC 3339 timestamp returned by SAC. It reports false for empty or malformed values.
+func parseTimestamp(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t.UTC(), true
+}
+
 func valOrFallback(value, fallback string) string {
 	if value != "" {
 		return value
 	}
 	return fallback
 }
+
+// wrapError annotates err with message and, for SAC API errors, converts it to a gRPC status
+// carrying the code that matches the HTTP status returned by SAC.
+func wrapError(err error, message string) error {
+	if errors.Is(err, sac.ErrMissingETag) {
+		return status.Errorf(codes.FailedPrecondition, "%s: %s", message, err)
+	}
+
+	var apiErr *sac.APIError
+	if !errors.As(err, &apiErr) {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	return status.Errorf(grpcCode(apiErr.StatusCode), "%s: %s", message, apiErr.Error())
+}
+
+func grpcCode(statusCode int) codes.Code {
+	switch {
+	case statusCode == http.StatusBadRequest:
+		return codes.InvalidArgument
+	case statusCode == http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case statusCode == http.StatusForbidden:
+		return codes.PermissionDenied
+	case statusCode == http.StatusNotFound:
+		return codes.NotFound
+	case statusCode == http.StatusConflict:
+		return codes.Aborted
+	case statusCode == http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case statusCode == http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case statusCode == http.StatusNotImplemented:
+		return codes.Unimplemented
+	case statusCode >= http.StatusInternalServerError:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+// rateLimitAnnotations returns a RateLimitDescription annotation with the rate limit state SAC last reported to
+// client, so the syncer can pace its calls, or nil when SAC reported none. The SDK drops the annotations of calls
+// that fail, so throttling errors are reported through their codes.ResourceExhausted status instead.
+func rateLimitAnnotations(client *sac.Client) annotations.Annotations {
+	rl := client.RateLimit()
+	if rl == nil {
+		return nil
+	}
+
+	desc := &v2.RateLimitDescription{
+		Status:    v2.RateLimitDescription_STATUS_OK,
+		Limit:     rl.Limit,
+		Remaining: rl.Remaining,
+	}
+	if rl.Remaining <= 0 {
+		desc.Status = v2.RateLimitDescription_STATUS_OVERLIMIT
+	}
+	if !rl.ResetAt.IsZero() {
+		desc.ResetAt = timestamppb.New(rl.ResetAt)
+	}
+
+	annos := annotations.Annotations{}
+	annos.WithRateLimiting(desc)
+
+	return annos
+}
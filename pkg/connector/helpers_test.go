@@ -0,0 +1,161 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestServer(t *testing.T) *sactest.Server {
+	t.Helper()
+
+	srv := sactest.NewServer(sactest.DefaultFixtures())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func requireCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+
+	if status.Code(err) != code {
+		t.Fatalf("got error %v, want code %s", err, code)
+	}
+}
+
+func identityProviderID(id string) *v2.ResourceId {
+	return &v2.ResourceId{ResourceType: identityProviderResourceType.Id, Resource: id}
+}
+
+// resourceIDs returns the IDs of resources.
+func resourceIDs(resources []*v2.Resource) []string {
+	var rv []string
+	for _, r := range resources {
+		rv = append(rv, r.Id.Resource)
+	}
+	return rv
+}
+
+// listAll calls List until the builder returns an empty page token, and returns every listed resource and the
+// number of calls made.
+func listAll(t *testing.T, builder connectorbuilder.ResourceSyncer, parentResourceID *v2.ResourceId) ([]*v2.Resource, int) {
+	t.Helper()
+
+	var rv []*v2.Resource
+	token := ""
+	for calls := 1; ; calls++ {
+		resources, next, _, err := builder.List(context.Background(), parentResourceID, &pagination.Token{Token: token})
+		if err != nil {
+			t.Fatalf("listing %s: %v", builder.ResourceType(context.Background()).Id, err)
+		}
+		rv = append(rv, resources...)
+
+		if next == "" {
+			return rv, calls
+		}
+		if calls > 100 {
+			t.Fatalf("listing %s did not end", builder.ResourceType(context.Background()).Id)
+		}
+		token = next
+	}
+}
+
+func accountID() *v2.ResourceId {
+	return &v2.ResourceId{ResourceType: accountResourceType.Id, Resource: "tenant"}
+}
+
+// testEntitlement returns the only entitlement the builder reports for resource.
+func testEntitlement(t *testing.T, builder connectorbuilder.ResourceSyncer, resource *v2.Resource) *v2.Entitlement {
+	t.Helper()
+
+	entitlements, _, _, err := builder.Entitlements(context.Background(), resource, nil)
+	if err != nil {
+		t.Fatalf("listing entitlements of %s: %v", resource.Id.Resource, err)
+	}
+	if len(entitlements) != 1 {
+		t.Fatalf("got %d entitlements for %s, want 1", len(entitlements), resource.Id.Resource)
+	}
+
+	return entitlements[0]
+}
+
+// testUser returns the resource of a user of the default fixtures.
+func testUser(t *testing.T, userId string) *v2.Resource {
+	t.Helper()
+
+	for _, users := range sactest.DefaultFixtures().Users {
+		for _, u := range users {
+			if u.ID != userId {
+				continue
+			}
+
+			ur, err := userResource(&u, nil, "", identityProviderID(u.IdentityProviderID))
+			if err != nil {
+				t.Fatalf("building user %s: %v", userId, err)
+			}
+			return ur
+		}
+	}
+
+	t.Fatalf("user %s not in the fixtures", userId)
+	return nil
+}
+
+// testGroup returns the resource of a group of the default fixtures.
+func testGroup(t *testing.T, groupId string) *v2.Resource {
+	t.Helper()
+
+	for _, groups := range sactest.DefaultFixtures().Groups {
+		for _, g := range groups {
+			if g.ID != groupId {
+				continue
+			}
+
+			gr, err := groupResource(&g, "", identityProviderID(g.IdentityProviderID))
+			if err != nil {
+				t.Fatalf("building group %s: %v", groupId, err)
+			}
+			return gr
+		}
+	}
+
+	t.Fatalf("group %s not in the fixtures", groupId)
+	return nil
+}
+
+// groupMembers returns the IDs of the members of a group as stored by the server.
+func groupMembers(srv *sactest.Server, groupId string) []string {
+	var rv []string
+	srv.Update(func(f *sactest.Fixtures) {
+		rv = append(rv, f.GroupMembers[groupId]...)
+	})
+	return rv
+}
+
+func contains(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// roleBindings returns the bindings of a role as stored by the server.
+func roleBindings(srv *sactest.Server, roleId string) []sac.RoleBinding {
+	var rv []sac.RoleBinding
+	srv.Update(func(f *sactest.Fixtures) {
+		for _, binding := range f.RoleBindings {
+			if binding.RoleID == roleId {
+				rv = append(rv, binding)
+			}
+		}
+	})
+	return rv
+}
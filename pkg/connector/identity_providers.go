@@ -0,0 +1,114 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type identityProviderBuilder struct {
+	resourceType *v2.ResourceType
+	client       *sac.Client
+	portalURL    string
+}
+
+func (i *identityProviderBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return i.resourceType
+}
+
+func identityProviderResource(identityProvider *sac.IdentityProvider, portalURL string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"identity_provider_id":   identityProvider.ID,
+		"identity_provider_name": identityProvider.Name,
+		"provider_type":          identityProvider.Provider,
+		"is_authenticator":       identityProvider.IsAuthenticator,
+		"is_user_store":          identityProvider.IsUserStore,
+	}
+
+	// The authenticator is the identity provider users of a user store sign in with, it is null for authenticators.
+	if identityProvider.AuthenticatorID != nil {
+		profile["authenticator_id"] = fmt.Sprint(identityProvider.AuthenticatorID)
+	}
+
+	appTraitOptions := []rs.AppTraitOption{rs.WithAppProfile(profile)}
+
+	ret, err := rs.NewAppResource(
+		identityProvider.Name,
+		identityProviderResourceType,
+		identityProvider.ID,
+		appTraitOptions,
+		rs.WithParentResourceID(parentResourceID),
+		rs.WithDescription(fmt.Sprintf("%s identity provider", identityProvider.Provider)),
+		withPortalLink(portalURL, "identities", identityProvider.ID),
+		rs.WithAnnotation(
+			&v2.ChildResourceType{ResourceTypeId: userResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: groupResourceType.Id},
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (i *identityProviderBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	identityProviders, err := i.client.ListIdentityProviders(ctx)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list identity providers")
+	}
+
+	var rv []*v2.Resource
+	for _, identityProvider := range identityProviders {
+		identityProviderCopy := identityProvider
+		ir, err := identityProviderResource(&identityProviderCopy, i.portalURL, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		rv = append(rv, ir)
+	}
+
+	return rv, "", rateLimitAnnotations(i.client), nil
+}
+
+func (i *identityProviderBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func (i *identityProviderBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// findLocalIdentityProvider returns the local identity provider of the tenant, failing with codes.FailedPrecondition
+// when there is none.
+func findLocalIdentityProvider(ctx context.Context, client *sac.Client) (sac.IdentityProvider, error) {
+	identityProvider, err := client.LocalIdentityProvider(ctx)
+	if err != nil {
+		if errors.Is(err, sac.ErrNoLocalIdentityProvider) {
+			return sac.IdentityProvider{}, status.Error(codes.FailedPrecondition, "baton-broadcom-sac: the tenant has no local identity provider")
+		}
+		return sac.IdentityProvider{}, wrapError(err, "failed to list identity providers")
+	}
+
+	return identityProvider, nil
+}
+
+func newIdentityProviderBuilder(client *sac.Client, portalURL string) *identityProviderBuilder {
+	return &identityProviderBuilder{
+		resourceType: identityProviderResourceType,
+		client:       client,
+		portalURL:    portalURL,
+	}
+}
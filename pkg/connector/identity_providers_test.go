@@ -0,0 +1,79 @@
+package connector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestIdentityProviderList(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Update(func(f *sactest.Fixtures) {
+		f.IdentityProviders = append(f.IdentityProviders,
+			sac.IdentityProvider{ID: "ldap-idp", Name: "LDAP", Provider: "LDAP", IsUserStore: true, AuthenticatorID: sactest.OktaIdentityProviderID})
+	})
+	i := newIdentityProviderBuilder(srv.NewClient(), "")
+
+	resources, token, _, err := i.List(context.Background(), accountID(), &pagination.Token{})
+	if err != nil {
+		t.Fatalf("listing identity providers: %v", err)
+	}
+	if got := strings.Join(resourceIDs(resources), ","); got != "local-idp,okta-idp,ldap-idp" || token != "" {
+		t.Fatalf("got identity providers %s and token %q, want local-idp,okta-idp,ldap-idp and no token", got, token)
+	}
+
+	for _, ir := range resources {
+		if ir.ParentResourceId.Resource != accountID().Resource {
+			t.Errorf("identity provider %s has parent %v, want the account", ir.Id.Resource, ir.ParentResourceId)
+		}
+
+		var children []string
+		for _, a := range ir.Annotations {
+			child := &v2.ChildResourceType{}
+			if a.MessageIs(child) {
+				if err := a.UnmarshalTo(child); err != nil {
+					t.Fatalf("reading annotation: %v", err)
+				}
+				children = append(children, child.ResourceTypeId)
+			}
+		}
+		if got := strings.Join(children, ","); got != "user,group" {
+			t.Errorf("identity provider %s has children %s, want user,group", ir.Id.Resource, got)
+		}
+	}
+
+	trait, err := rs.GetAppTrait(resources[2])
+	if err != nil {
+		t.Fatalf("reading the app trait: %v", err)
+	}
+	if got, _ := rs.GetProfileStringValue(trait.Profile, "provider_type"); got != "LDAP" {
+		t.Errorf("got provider type %q, want LDAP", got)
+	}
+	if got, _ := rs.GetProfileStringValue(trait.Profile, "authenticator_id"); got != sactest.OktaIdentityProviderID {
+		t.Errorf("got authenticator %q, want %s", got, sactest.OktaIdentityProviderID)
+	}
+
+	trait, err = rs.GetAppTrait(resources[0])
+	if err != nil {
+		t.Fatalf("reading the app trait: %v", err)
+	}
+	if _, ok := rs.GetProfileStringValue(trait.Profile, "authenticator_id"); ok {
+		t.Error("got an authenticator for an identity provider that is its own authenticator")
+	}
+}
+
+func TestIdentityProviderListSkipsOtherParents(t *testing.T) {
+	srv := newTestServer(t)
+	i := newIdentityProviderBuilder(srv.NewClient(), "")
+
+	resources, _, _, err := i.List(context.Background(), nil, &pagination.Token{})
+	if err != nil || len(resources) != 0 {
+		t.Errorf("got %d identity providers and error %v without a parent, want none", len(resources), err)
+	}
+}
@@ -0,0 +1,50 @@
+package connector
+
+import (
+	"context"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+)
+
+// Users and groups of the local identity provider are created and deleted through the baton-sdk account and resource
+// managers too, but the SDK has no RPC to block a user or rename a group, so their lifecycle is also exposed as
+// methods of the Connector, used by the users and groups commands.
+
+// CreateLocalUser creates a user in the local identity provider, adds it to the groups given by ID and returns
+// its resource.
+func (c *Connector) CreateLocalUser(ctx context.Context, user sac.LocalUser, groupIds []string) (*v2.Resource, error) {
+	return newUserBuilder(c.client, c.portalURL, 0).createLocalUser(ctx, user, groupIds)
+}
+
+// BlockLocalUser blocks a user of the local identity provider, which is then synced as disabled.
+func (c *Connector) BlockLocalUser(ctx context.Context, userId string) error {
+	return newUserBuilder(c.client, c.portalURL, 0).setLocalUserBlocked(ctx, userId, true)
+}
+
+// UnblockLocalUser unblocks a user of the local identity provider.
+func (c *Connector) UnblockLocalUser(ctx context.Context, userId string) error {
+	return newUserBuilder(c.client, c.portalURL, 0).setLocalUserBlocked(ctx, userId, false)
+}
+
+// DeleteLocalUser deletes a user of the local identity provider.
+func (c *Connector) DeleteLocalUser(ctx context.Context, userId string) error {
+	return newUserBuilder(c.client, c.portalURL, 0).deleteLocalUser(ctx, userId)
+}
+
+// CreateLocalGroup creates a group in the local identity provider and returns its resource. It fails with
+// codes.AlreadyExists when a group of that name exists.
+func (c *Connector) CreateLocalGroup(ctx context.Context, name string) (*v2.Resource, error) {
+	return newGroupBuilder(c.client, c.portalURL).createLocalGroup(ctx, name)
+}
+
+// RenameLocalGroup renames a group of the local identity provider and returns its resource. It fails with
+// codes.AlreadyExists when another group has that name.
+func (c *Connector) RenameLocalGroup(ctx context.Context, groupId string, name string) (*v2.Resource, error) {
+	return newGroupBuilder(c.client, c.portalURL).renameLocalGroup(ctx, groupId, name)
+}
+
+// DeleteLocalGroup deletes a group of the local identity provider.
+func (c *Connector) DeleteLocalGroup(ctx context.Context, groupId string) error {
+	return newGroupBuilder(c.client, c.portalURL).deleteLocalGroup(ctx, groupId)
+}
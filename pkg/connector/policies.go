@@ -16,6 +16,7 @@ import (
 type policyBuilder struct {
 	resourceType *v2.ResourceType
 	client       *sac.Client
+	portalURL    string
 }
 
 const (
@@ -28,12 +29,43 @@ func (p *policyBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 	return p.resourceType
 }
 
-func policyResource(policy *sac.Policy, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
-	ret, err := rs.NewResource(
+func policyResource(policy *sac.Policy, portalURL string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"policy_id":    policy.ID,
+		"policy_name":  policy.Name,
+		"policy_type":  policy.Type,
+		"enabled":      policy.Enabled,
+		"applications": profileList(policy.Applications),
+		"source_ips":   profileList(policy.Conditions.SourceIP),
+		"locations":    profileList(policy.Conditions.Location),
+	}
+
+	if device := policy.Conditions.ManagedDevice; device != nil {
+		profile["managed_device_opswat_meta_access"] = device.OpswatMetaAccess
+		profile["managed_device_symantec_cloud_soc"] = device.SymantecCloudSoc
+		profile["managed_device_symantec_web_security_service"] = device.SymantecWebSecurityService
+	}
+
+	if ssh := policy.SSHSettings; ssh != nil {
+		profile["ssh_accounts"] = profileList(ssh.Accounts)
+		profile["ssh_auto_mapping"] = ssh.AutoMapping
+		profile["ssh_full_upn_auto_mapping"] = ssh.FullUPNAutoMapping
+		profile["ssh_agent_forward"] = ssh.AgentForward
+	}
+
+	if rdp := policy.RDPSettings; rdp != nil {
+		profile["rdp_long_term_password"] = rdp.LongTermPassword
+	}
+
+	appTraitOptions := []rs.AppTraitOption{rs.WithAppProfile(profile)}
+
+	ret, err := rs.NewAppResource(
 		policy.Name,
 		policyResourceType,
 		policy.ID,
+		appTraitOptions,
 		rs.WithParentResourceID(parentResourceID),
+		withPortalLink(portalURL, "policies", policy.ID),
 	)
 	if err != nil {
 		return nil, err
@@ -42,26 +74,45 @@ func policyResource(policy *sac.Policy, parentResourceID *v2.ResourceId) (*v2.Re
 	return ret, nil
 }
 
-func (p *policyBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+// profileList converts values to a list a resource profile can hold.
+func profileList(values []string) []interface{} {
+	rv := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		rv = append(rv, value)
+	}
+	return rv
+}
+
+func (p *policyBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
 	if parentResourceID == nil {
 		return nil, "", nil, nil
 	}
 
-	policies, err := p.client.ListAllPolicies(ctx)
+	pageNumber, err := parsePageNumber(pToken.Token)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("failed to list policies: %w", err)
+		return nil, "", nil, err
+	}
+
+	policies, paginationData, err := p.client.ListPolicies(ctx, pageNumber)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list policies")
+	}
+
+	token, err := nextPageNumberToken(pageNumber, len(policies), paginationData)
+	if err != nil {
+		return nil, "", nil, err
 	}
 
 	var rv []*v2.Resource
 	for _, policy := range policies {
 		policyCopy := policy
-		gr, err := policyResource(&policyCopy, parentResourceID)
+		gr, err := policyResource(&policyCopy, p.portalURL, parentResourceID)
 		if err != nil {
 			return nil, "", nil, err
 		}
 		rv = append(rv, gr)
 	}
-	return rv, "", nil, nil
+	return rv, token, rateLimitAnnotations(p.client), nil
 }
 
 func (p *policyBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
@@ -79,44 +130,124 @@ func (p *policyBuilder) Entitlements(_ context.Context, resource *v2.Resource, _
 	return rv, "", nil, nil
 }
 
+// Grants returns the users and groups a policy is assigned to; group assignments expand to the group members.
+// An enabled policy also grants access to each application it targets; those grants are expandable from the
+// policy assignment, so access to an application follows the policy. Applications that no longer exist are skipped.
 func (p *policyBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
 	policy, err := p.client.GetPolicy(ctx, resource.Id.Resource)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("failed to get policy: %w", err)
+		return nil, "", nil, wrapError(err, "failed to get policy")
 	}
 
 	var rv []*v2.Grant
 	for _, entity := range policy.DirectoryEntities {
 		entityCopy := entity
+		grant, err := directoryEntityGrant(resource, assignmentEntitlement, &entityCopy)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if grant != nil {
+			rv = append(rv, grant)
+		}
+	}
 
-		switch entityCopy.Type {
-		case user:
-			ur, err := baseUserResource(&entityCopy, resource.Id)
-			if err != nil {
-				return nil, "", nil, err
+	if !policy.Enabled {
+		return rv, "", rateLimitAnnotations(p.client), nil
+	}
+
+	assignment := ent.NewEntitlementID(resource, assignmentEntitlement)
+	var collections map[string]string
+	for _, applicationID := range policy.Applications {
+		application, err := p.client.GetApplication(ctx, applicationID)
+		if err != nil {
+			if sac.IsNotFound(err) {
+				continue
 			}
-			grant := grant.NewGrant(resource, assignmentEntitlement, ur.Id)
-			rv = append(rv, grant)
+			return nil, "", nil, wrapError(err, "failed to get application")
+		}
 
-		case group:
-			gr, err := baseGroupResource(&entityCopy, resource.Id)
+		// The application is built as applicationBuilder.List builds it, so the grant lands on the synced resource.
+		if application.SiteID == "" && collections == nil {
+			collections, err = collectionOf(ctx, p.client)
 			if err != nil {
-				return nil, "", nil, err
+				return nil, "", nil, wrapError(err, "failed to list collections")
 			}
-			grant := grant.NewGrant(resource, assignmentEntitlement, gr.Id)
-			rv = append(rv, grant)
+		}
+		ar, err := applicationResource(&application, p.portalURL, applicationParentResourceID(&application, collections, resource.ParentResourceId))
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, grant.NewGrant(
+			ar,
+			accessEntitlement,
+			resource.Id,
+			grant.WithAnnotation(&v2.GrantExpandable{EntitlementIds: []string{assignment}}),
+		))
+	}
+
+	return rv, "", rateLimitAnnotations(p.client), nil
+}
+
+// Grant assigns a policy to a user or group.
+func (p *policyBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	ctx = sac.WithoutCache(ctx)
+
+	entity, err := principalDirectoryEntity(principal)
+	if err != nil {
+		return nil, err
+	}
 
-		default:
-			continue
+	err = p.client.ModifyPolicyDirectoryEntities(ctx, entitlement.Resource.Id.Resource, func(entities []sac.DirectoryEntity) ([]sac.DirectoryEntity, bool) {
+		if indexOfDirectoryEntity(entities, entity) >= 0 {
+			return entities, false
 		}
+		return append(entities, entity), true
+	})
+	if err != nil {
+		return nil, wrapError(err, "failed to assign policy")
 	}
 
-	return rv, "", nil, nil
+	return rateLimitAnnotations(p.client), nil
+}
+
+// Revoke removes a user or group from the directory entities a policy is assigned to.
+func (p *policyBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	ctx = sac.WithoutCache(ctx)
+
+	entity, err := principalDirectoryEntity(grant.Principal)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.client.ModifyPolicyDirectoryEntities(ctx, grant.Entitlement.Resource.Id.Resource, func(entities []sac.DirectoryEntity) ([]sac.DirectoryEntity, bool) {
+		i := indexOfDirectoryEntity(entities, entity)
+		if i < 0 {
+			return entities, false
+		}
+		return append(entities[:i:i], entities[i+1:]...), true
+	})
+	if err != nil {
+		return nil, wrapError(err, "failed to unassign policy")
+	}
+
+	return rateLimitAnnotations(p.client), nil
+}
+
+// indexOfDirectoryEntity returns the index of the entity of the same type and ID as entity, or -1.
+func indexOfDirectoryEntity(entities []sac.DirectoryEntity, entity sac.DirectoryEntity) int {
+	for i, e := range entities {
+		if e.Type == entity.Type && e.ID == entity.ID {
+			return i
+		}
+	}
+	return -1
 }
 
-func newPolicyBuilder(client *sac.Client) *policyBuilder {
+func newPolicyBuilder(client *sac.Client, portalURL string) *policyBuilder {
 	return &policyBuilder{
 		resourceType: policyResourceType,
 		client:       client,
+		portalURL:    portalURL,
 	}
 }
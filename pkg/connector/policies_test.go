@@ -0,0 +1,290 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+// testPolicy returns the resource of a policy of the default fixtures, listed under the account.
+func testPolicy(t *testing.T, policyId string) *v2.Resource {
+	t.Helper()
+
+	for _, p := range sactest.DefaultFixtures().Policies {
+		if p.ID != policyId {
+			continue
+		}
+
+		pr, err := policyResource(&p, "", accountID())
+		if err != nil {
+			t.Fatalf("building policy %s: %v", policyId, err)
+		}
+		return pr
+	}
+
+	t.Fatalf("policy %s not in the fixtures", policyId)
+	return nil
+}
+
+func TestPolicyListReturnsOnePagePerCall(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	p := newPolicyBuilder(srv.NewClient(sac.WithPageSize(1)), "")
+
+	resources, token, _, err := p.List(ctx, accountID(), &pagination.Token{})
+	if err != nil {
+		t.Fatalf("listing the first page: %v", err)
+	}
+	if len(resources) != 1 || resources[0].Id.Resource != "policy-ssh" || token != "1" {
+		t.Fatalf("got %v and token %q, want policy-ssh and token 1", resourceIDs(resources), token)
+	}
+	if got := srv.Requests(http.MethodGet, "/v2/policies"); got != 1 {
+		t.Errorf("got %d policy list requests for the first page, want 1", got)
+	}
+
+	resources, token, _, err = p.List(ctx, accountID(), &pagination.Token{Token: token})
+	if err != nil {
+		t.Fatalf("listing the second page: %v", err)
+	}
+	if len(resources) != 1 || resources[0].Id.Resource != "policy-web" || token != "" {
+		t.Fatalf("got %v and token %q, want policy-web and no token", resourceIDs(resources), token)
+	}
+	if got := srv.Requests(http.MethodGet, "/v2/policies"); got != 2 {
+		t.Errorf("got %d policy list requests, want 2", got)
+	}
+
+	if _, _, _, err := p.List(ctx, accountID(), &pagination.Token{Token: "next"}); err == nil {
+		t.Error("listed policies with an invalid page token")
+	}
+}
+
+func TestPolicyGrantsReachApplicationAccess(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := srv.NewClient()
+	a := newApplicationBuilder(client, "")
+	p := newPolicyBuilder(client, "")
+
+	site := &v2.ResourceId{ResourceType: siteResourceType.Id, Resource: "site-dc1"}
+	applications, _ := listAll(t, a, site)
+	var access *v2.Entitlement
+	for _, ar := range applications {
+		if ar.Id.Resource == "app-ssh" {
+			access = testEntitlement(t, a, ar)
+		}
+	}
+	if access == nil {
+		t.Fatalf("got applications %v under site-dc1, want app-ssh", resourceIDs(applications))
+	}
+
+	ssh := testPolicy(t, "policy-ssh")
+	grants, _, _, err := p.Grants(ctx, ssh, &pagination.Token{})
+	if err != nil {
+		t.Fatalf("listing grants: %v", err)
+	}
+
+	var found *v2.Grant
+	for _, g := range grants {
+		if g.Entitlement.Id == access.Id {
+			found = g
+		}
+	}
+	if found == nil {
+		t.Fatalf("no grant of %s among the policy grants", access.Id)
+	}
+	if parent := found.Entitlement.Resource.ParentResourceId; parent.ResourceType != site.ResourceType || parent.Resource != site.Resource {
+		t.Errorf("got application parent %v, want site-dc1", parent)
+	}
+	if found.Principal.Id.Resource != "policy-ssh" {
+		t.Errorf("got principal %s, want policy-ssh", found.Principal.Id.Resource)
+	}
+
+	expandable := &v2.GrantExpandable{}
+	annos := annotations.Annotations(found.Annotations)
+	ok, err := annos.Pick(expandable)
+	if err != nil || !ok {
+		t.Fatalf("got no expandable annotation (%v)", err)
+	}
+	if assigned := testEntitlement(t, p, ssh); len(expandable.EntitlementIds) != 1 || expandable.EntitlementIds[0] != assigned.Id {
+		t.Errorf("got expandable entitlements %v, want %s", expandable.EntitlementIds, assigned.Id)
+	}
+}
+
+func TestPolicyGrantsSkipDeletedApplications(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	srv.Update(func(f *sactest.Fixtures) {
+		f.Applications = f.Applications[:1]
+	})
+	p := newPolicyBuilder(srv.NewClient(), "")
+
+	grants, _, _, err := p.Grants(ctx, testPolicy(t, "policy-web"), &pagination.Token{})
+	if err != nil {
+		t.Fatalf("listing grants: %v", err)
+	}
+	for _, g := range grants {
+		if g.Entitlement.Resource.Id.ResourceType == applicationResourceType.Id {
+			t.Errorf("got grant %s of a deleted application", g.Id)
+		}
+	}
+}
+
+func TestPolicyProfile(t *testing.T) {
+	policy := sac.Policy{
+		ID:           "policy-ssh",
+		Name:         "SSH access",
+		Type:         "SSH",
+		Enabled:      true,
+		Applications: []string{"app-ssh"},
+		Conditions: sac.PolicyConditions{
+			SourceIP:      []string{"10.0.0.0/8"},
+			ManagedDevice: &sac.ManagedDeviceCondition{SymantecCloudSoc: true},
+		},
+		SSHSettings: &sac.SSHSettings{Accounts: []string{"ubuntu", "root"}, AgentForward: true},
+		RDPSettings: &sac.RDPSettings{LongTermPassword: true},
+	}
+
+	pr, err := policyResource(&policy, "", accountID())
+	if err != nil {
+		t.Fatalf("building policy: %v", err)
+	}
+	trait, err := rs.GetAppTrait(pr)
+	if err != nil {
+		t.Fatalf("reading the app trait: %v", err)
+	}
+
+	fields := trait.Profile.GetFields()
+	if got := fields["source_ips"].GetListValue().AsSlice(); len(got) != 1 || got[0] != "10.0.0.0/8" {
+		t.Errorf("got source IPs %v, want 10.0.0.0/8", got)
+	}
+	if got := fields["locations"].GetListValue().AsSlice(); len(got) != 0 {
+		t.Errorf("got locations %v, want none", got)
+	}
+	if !fields["managed_device_symantec_cloud_soc"].GetBoolValue() || fields["managed_device_opswat_meta_access"].GetBoolValue() {
+		t.Error("got the wrong managed device conditions")
+	}
+	if got := fields["ssh_accounts"].GetListValue().AsSlice(); len(got) != 2 || got[1] != "root" {
+		t.Errorf("got SSH accounts %v, want ubuntu and root", got)
+	}
+	if !fields["ssh_agent_forward"].GetBoolValue() || fields["ssh_auto_mapping"].GetBoolValue() {
+		t.Error("got the wrong SSH settings")
+	}
+	if !fields["rdp_long_term_password"].GetBoolValue() {
+		t.Error("got the wrong RDP settings")
+	}
+
+	policy.SSHSettings, policy.RDPSettings = nil, nil
+	if pr, err = policyResource(&policy, "", accountID()); err != nil {
+		t.Fatalf("building policy: %v", err)
+	}
+	if trait, err = rs.GetAppTrait(pr); err != nil {
+		t.Fatalf("reading the app trait: %v", err)
+	}
+	for _, key := range []string{"ssh_accounts", "rdp_long_term_password"} {
+		if _, ok := trait.Profile.GetFields()[key]; ok {
+			t.Errorf("got %s for a policy without settings", key)
+		}
+	}
+}
+
+func TestDisabledPolicyGrantsReportRateLimit(t *testing.T) {
+	srv := newTestServer(t)
+	p := newPolicyBuilder(srv.NewClient(), "")
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "100")
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("X-RateLimit-Reset", "60")
+	srv.Inject(sactest.Fault{Path: "/v2/policies/policy-web", Status: http.StatusOK, Times: 1, Header: header, Body: `{"id":"policy-web","enabled":false}`})
+
+	grants, _, annos, err := p.Grants(context.Background(), testPolicy(t, "policy-web"), &pagination.Token{})
+	if err != nil {
+		t.Fatalf("listing grants: %v", err)
+	}
+	if len(grants) != 0 {
+		t.Errorf("got %d grants for a disabled policy without entities, want none", len(grants))
+	}
+	if ok, err := annos.Pick(&v2.RateLimitDescription{}); err != nil || !ok {
+		t.Errorf("got annotations %v, want a rate limit description", annos)
+	}
+}
+
+// policyEntities returns the IDs of the directory entities a policy is assigned to, as stored by the server.
+func policyEntities(srv *sactest.Server, policyId string) []string {
+	var rv []string
+	srv.Update(func(f *sactest.Fixtures) {
+		for _, p := range f.Policies {
+			if p.ID != policyId {
+				continue
+			}
+			for _, entity := range p.DirectoryEntities {
+				rv = append(rv, entity.ID)
+			}
+		}
+	})
+	return rv
+}
+
+func TestPolicyGrantAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	p := newPolicyBuilder(srv.NewClient(), "")
+
+	ssh := testPolicy(t, "policy-ssh")
+	assigned := testEntitlement(t, p, ssh)
+	admins := testGroup(t, "group-admins")
+
+	if _, err := p.Grant(ctx, admins, assigned); err != nil {
+		t.Fatalf("granting: %v", err)
+	}
+	if entities := policyEntities(srv, "policy-ssh"); len(entities) != 2 || !contains(entities, "group-admins") {
+		t.Fatalf("got entities %v after the grant, want user-alice and group-admins", entities)
+	}
+
+	// Granting an existing assignment does not write the policy.
+	if _, err := p.Grant(ctx, admins, assigned); err != nil {
+		t.Fatalf("granting again: %v", err)
+	}
+	if got := srv.Requests(http.MethodPut, "/v2/policies/policy-ssh"); got != 1 {
+		t.Errorf("got %d policy updates, want 1", got)
+	}
+
+	if _, err := p.Revoke(ctx, &v2.Grant{Entitlement: assigned, Principal: testUser(t, "user-alice")}); err != nil {
+		t.Fatalf("revoking: %v", err)
+	}
+	if entities := policyEntities(srv, "policy-ssh"); len(entities) != 1 || entities[0] != "group-admins" {
+		t.Fatalf("got entities %v after the revoke, want group-admins", entities)
+	}
+}
+
+func TestPolicyGrantSeesChangesCachedDuringSync(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := srv.NewClient()
+	p := newPolicyBuilder(client, "")
+
+	ssh := testPolicy(t, "policy-ssh")
+	assigned := testEntitlement(t, p, ssh)
+
+	// A sync caches the policy, then it changes in the admin portal.
+	if _, err := client.GetPolicy(ctx, "policy-ssh"); err != nil {
+		t.Fatalf("getting policy: %v", err)
+	}
+	srv.Update(func(f *sactest.Fixtures) {
+		f.Policies[0].DirectoryEntities = []sac.DirectoryEntity{}
+	})
+
+	if _, err := p.Grant(ctx, testUser(t, "user-bob"), assigned); err != nil {
+		t.Fatalf("granting: %v", err)
+	}
+	if entities := policyEntities(srv, "policy-ssh"); len(entities) != 1 || entities[0] != "user-bob" {
+		t.Fatalf("got entities %v after the grant, want user-bob", entities)
+	}
+}
@@ -18,9 +18,41 @@ var (
 	accountResourceType = &v2.ResourceType{
 		Id:          "account",
 		DisplayName: "Account",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_APP},
 	}
 	policyResourceType = &v2.ResourceType{
 		Id:          "policy",
 		DisplayName: "Policy",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_APP},
+	}
+	applicationResourceType = &v2.ResourceType{
+		Id:          "application",
+		DisplayName: "Application",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_APP},
+	}
+	roleResourceType = &v2.ResourceType{
+		Id:          "role",
+		DisplayName: "Role",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_ROLE},
+	}
+	collectionResourceType = &v2.ResourceType{
+		Id:          "collection",
+		DisplayName: "Collection",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_APP},
+	}
+	siteResourceType = &v2.ResourceType{
+		Id:          "site",
+		DisplayName: "Site",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_APP},
+	}
+	connectorResourceType = &v2.ResourceType{
+		Id:          "connector",
+		DisplayName: "Connector",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_APP},
+	}
+	identityProviderResourceType = &v2.ResourceType{
+		Id:          "identity_provider",
+		DisplayName: "Identity Provider",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_APP},
 	}
 )
@@ -0,0 +1,345 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// tenantRoleType is the type of the roles that can only be bound on the whole tenant.
+const tenantRoleType = "tenant"
+
+type roleBuilder struct {
+	resourceType *v2.ResourceType
+	client       *sac.Client
+	portalURL    string
+}
+
+func (r *roleBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return r.resourceType
+}
+
+func roleResource(role *sac.Role, portalURL string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"role_id":   role.ID,
+		"role_name": role.Name,
+		"role_type": role.Type,
+	}
+
+	roleTraitOptions := []rs.RoleTraitOption{rs.WithRoleProfile(profile)}
+
+	resourceOptions := []rs.ResourceOption{
+		rs.WithParentResourceID(parentResourceID),
+		withPortalLink(portalURL, "roles", role.ID),
+	}
+	if role.Description != "" {
+		resourceOptions = append(resourceOptions, rs.WithDescription(role.Description))
+	}
+
+	ret, err := rs.NewRoleResource(
+		role.Name,
+		roleResourceType,
+		role.ID,
+		roleTraitOptions,
+		resourceOptions...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *roleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	roles, err := r.client.ListRoles(ctx)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list roles")
+	}
+
+	var rv []*v2.Resource
+	for _, role := range roles {
+		roleCopy := role
+		rr, err := roleResource(&roleCopy, r.portalURL, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		rv = append(rv, rr)
+	}
+
+	return rv, "", rateLimitAnnotations(r.client), nil
+}
+
+// Entitlements returns the assignment entitlement of tenant roles. Other roles are bound on collections, so they
+// are entitlements of the collection resources instead.
+func (r *roleBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	if !isTenantRole(resource) {
+		return nil, "", nil, nil
+	}
+
+	var rv []*v2.Entitlement
+
+	assigmentOptions := []ent.EntitlementOption{
+		ent.WithGrantableTo(userResourceType, groupResourceType),
+		ent.WithDescription(fmt.Sprintf("Assigned to %s role", resource.DisplayName)),
+		ent.WithDisplayName(fmt.Sprintf("%s Role %s", resource.DisplayName, assignmentEntitlement)),
+	}
+
+	en := ent.NewAssignmentEntitlement(resource, assignmentEntitlement, assigmentOptions...)
+	rv = append(rv, en)
+
+	return rv, "", nil, nil
+}
+
+// Grants returns the users and groups bound to a tenant role on the whole tenant. Other roles are bound on
+// collections, so their grants are listed by the collection resources instead.
+// SAC lists role bindings for all roles at once; the binding pages are shared by every role through the client's
+// per-sync cache, so bindings are only downloaded once per sync.
+func (r *roleBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	if !isTenantRole(resource) {
+		return nil, "", nil, nil
+	}
+
+	bindings, err := r.client.ListAllRoleBindings(ctx)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list role bindings")
+	}
+
+	var rv []*v2.Grant
+	for _, binding := range tenantRoleBindings(bindings, resource.Id.Resource, nil) {
+		bindingCopy := binding
+		grant, err := directoryEntityGrant(resource, assignmentEntitlement, &bindingCopy.Entity)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if grant != nil {
+			rv = append(rv, grant)
+		}
+	}
+
+	return rv, "", rateLimitAnnotations(r.client), nil
+}
+
+// Grant binds a tenant role to a user or group on the whole tenant.
+func (r *roleBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	ctx = sac.WithoutCache(ctx)
+
+	entity, err := principalDirectoryEntity(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := r.tenantRole(ctx, entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	bindings, err := r.client.ListAllRoleBindings(ctx)
+	if err != nil {
+		return nil, wrapError(err, "failed to list role bindings")
+	}
+
+	if len(tenantRoleBindings(bindings, role.ID, &entity)) > 0 {
+		return nil, nil
+	}
+
+	_, err = r.client.CreateRoleBinding(ctx, sac.RoleBinding{
+		RoleID: role.ID,
+		Entity: entity,
+	})
+	if err != nil {
+		return nil, wrapError(err, "failed to create role binding")
+	}
+
+	return rateLimitAnnotations(r.client), nil
+}
+
+// Revoke removes the tenant bindings of a tenant role to a user or group. It refuses to remove tenant admin
+// bindings when no user would be left a tenant admin, either directly or through a group, and restores them when
+// the tenant turns out to have no admin after they were removed.
+func (r *roleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	ctx = sac.WithoutCache(ctx)
+
+	entity, err := principalDirectoryEntity(grant.Principal)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := r.tenantRole(ctx, grant.Entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	bindings, err := r.client.ListAllRoleBindings(ctx)
+	if err != nil {
+		return nil, wrapError(err, "failed to list role bindings")
+	}
+
+	revoked := tenantRoleBindings(bindings, role.ID, &entity)
+	if len(revoked) == 0 {
+		return nil, nil
+	}
+
+	if role.IsTenantAdmin() {
+		var remaining []sac.RoleBinding
+		for _, binding := range tenantRoleBindings(bindings, role.ID, nil) {
+			if binding.Entity.Type != entity.Type || binding.Entity.ID != entity.ID {
+				remaining = append(remaining, binding)
+			}
+		}
+
+		admins, err := r.boundUsers(ctx, remaining)
+		if err != nil {
+			return nil, wrapError(err, "failed to list tenant admins")
+		}
+
+		if len(admins) == 0 {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"baton-broadcom-sac: refusing to remove %s from the %s role, no other user is a tenant admin", grant.Principal.DisplayName, role.Name)
+		}
+	}
+
+	for _, binding := range revoked {
+		if err := r.client.DeleteRoleBinding(ctx, binding.ID); err != nil && !sac.IsNotFound(err) {
+			return nil, wrapError(err, "failed to delete role binding")
+		}
+	}
+
+	if role.IsTenantAdmin() {
+		if err := r.restoreLastTenantAdmin(ctx, role, revoked, grant.Principal.DisplayName); err != nil {
+			return nil, err
+		}
+	}
+
+	return rateLimitAnnotations(r.client), nil
+}
+
+// restoreLastTenantAdmin lists the tenant admin bindings again after revoked were deleted. The check made before the
+// delete cannot see bindings removed concurrently, e.g. in the admin portal, so when no tenant admin is left the
+// revoked bindings are created again and the revoke fails.
+func (r *roleBuilder) restoreLastTenantAdmin(ctx context.Context, role sac.Role, revoked []sac.RoleBinding, principalName string) error {
+	bindings, err := r.client.ListAllRoleBindings(ctx)
+	if err != nil {
+		return wrapError(err, "failed to list role bindings")
+	}
+
+	admins, err := r.boundUsers(ctx, tenantRoleBindings(bindings, role.ID, nil))
+	if err != nil {
+		return wrapError(err, "failed to list tenant admins")
+	}
+	if len(admins) > 0 {
+		return nil
+	}
+
+	for _, binding := range revoked {
+		_, err := r.client.CreateRoleBinding(ctx, sac.RoleBinding{
+			RoleID: role.ID,
+			Entity: binding.Entity,
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal,
+				"baton-broadcom-sac: removing %s from the %s role left the tenant without an admin and restoring the binding failed: %v",
+				principalName, role.Name, err)
+		}
+	}
+
+	return status.Errorf(codes.Aborted,
+		"baton-broadcom-sac: removing %s from the %s role left the tenant without an admin, the binding was restored", principalName, role.Name)
+}
+
+// tenantRole returns the role of a role resource, failing with codes.InvalidArgument when it is not a tenant role:
+// other roles are bound on collections.
+func (r *roleBuilder) tenantRole(ctx context.Context, resource *v2.Resource) (sac.Role, error) {
+	roles, err := r.client.ListRoles(ctx)
+	if err != nil {
+		return sac.Role{}, wrapError(err, "failed to list roles")
+	}
+
+	for _, role := range roles {
+		if role.ID != resource.Id.Resource {
+			continue
+		}
+
+		if role.Type != tenantRoleType {
+			return sac.Role{}, status.Errorf(codes.InvalidArgument,
+				"baton-broadcom-sac: the %s role is a %s role and can only be bound on a collection", role.Name, role.Type)
+		}
+
+		return role, nil
+	}
+
+	return sac.Role{}, status.Errorf(codes.NotFound, "baton-broadcom-sac: role %s not found", resource.Id.Resource)
+}
+
+// boundUsers returns the IDs of the distinct users bound by bindings, directly or as members of a bound group.
+func (r *roleBuilder) boundUsers(ctx context.Context, bindings []sac.RoleBinding) (map[string]struct{}, error) {
+	rv := make(map[string]struct{})
+	for _, binding := range bindings {
+		switch binding.Entity.Type {
+		case user:
+			rv[binding.Entity.ID] = struct{}{}
+		case group:
+			members, err := r.client.GroupMembersPager(binding.Entity.IdentityProviderID, binding.Entity.ID, 0).All(ctx)
+			if err != nil {
+				if sac.IsNotFound(err) {
+					continue
+				}
+				return nil, err
+			}
+
+			for _, member := range members {
+				rv[member.ID] = struct{}{}
+			}
+		}
+	}
+
+	return rv, nil
+}
+
+// tenantRoleBindings returns the bindings of a role on the whole tenant, restricted to those of entity when it is
+// not nil.
+func tenantRoleBindings(bindings []sac.RoleBinding, roleId string, entity *sac.DirectoryEntity) []sac.RoleBinding {
+	var rv []sac.RoleBinding
+	for _, binding := range bindings {
+		if binding.RoleID != roleId || binding.CollectionID != "" {
+			continue
+		}
+
+		if entity != nil && (binding.Entity.Type != entity.Type || binding.Entity.ID != entity.ID) {
+			continue
+		}
+
+		rv = append(rv, binding)
+	}
+	return rv
+}
+
+// isTenantRole reports whether a role resource is a tenant role.
+func isTenantRole(resource *v2.Resource) bool {
+	roleTrait, err := rs.GetRoleTrait(resource)
+	if err != nil {
+		return false
+	}
+
+	roleType, _ := rs.GetProfileStringValue(roleTrait.Profile, "role_type")
+	return roleType == tenantRoleType
+}
+
+func newRoleBuilder(client *sac.Client, portalURL string) *roleBuilder {
+	return &roleBuilder{
+		resourceType: roleResourceType,
+		client:       client,
+		portalURL:    portalURL,
+	}
+}
@@ -0,0 +1,264 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"google.golang.org/grpc/codes"
+)
+
+// testRole returns the resource of a role of the default fixtures, listed under the account.
+func testRole(t *testing.T, roleId string) *v2.Resource {
+	t.Helper()
+
+	for _, r := range sactest.DefaultFixtures().Roles {
+		if r.ID != roleId {
+			continue
+		}
+
+		rr, err := roleResource(&r, "", accountID())
+		if err != nil {
+			t.Fatalf("building role %s: %v", roleId, err)
+		}
+		return rr
+	}
+
+	t.Fatalf("role %s not in the fixtures", roleId)
+	return nil
+}
+
+func TestRoleGrantsPageBindingsOncePerSync(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	r := newRoleBuilder(srv.NewClient(sac.WithPageSize(1)), "")
+
+	want := map[string]string{
+		"role-tenant-admin":  "user-alice",
+		"role-tenant-viewer": "group-engineering",
+	}
+	for roleId, principal := range want {
+		grants, token, _, err := r.Grants(ctx, testRole(t, roleId), &pagination.Token{})
+		if err != nil {
+			t.Fatalf("listing grants of %s: %v", roleId, err)
+		}
+		if token != "" {
+			t.Errorf("got page token %q for %s, want all grants at once", token, roleId)
+		}
+		if len(grants) != 1 || grants[0].Principal.Id.Resource != principal {
+			t.Errorf("got %d grants of %s, want one to %s", len(grants), roleId, principal)
+		}
+	}
+
+	// The three pages of one binding are downloaded once for both roles.
+	if got := srv.Requests(http.MethodGet, "/v2/roles/bindings"); got != 3 {
+		t.Errorf("got %d role binding requests, want 3", got)
+	}
+}
+
+func TestRoleSkipsCollectionRoles(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	r := newRoleBuilder(srv.NewClient(), "")
+
+	collectionAdmin := testRole(t, "role-collection-admin")
+	entitlements, _, _, err := r.Entitlements(ctx, collectionAdmin, nil)
+	if err != nil {
+		t.Fatalf("listing entitlements: %v", err)
+	}
+	if len(entitlements) != 0 {
+		t.Errorf("got %d entitlements for a collection role, want none", len(entitlements))
+	}
+
+	grants, _, _, err := r.Grants(ctx, collectionAdmin, &pagination.Token{})
+	if err != nil {
+		t.Fatalf("listing grants: %v", err)
+	}
+	if len(grants) != 0 {
+		t.Errorf("got %d grants for a collection role, want none", len(grants))
+	}
+	if got := srv.Requests(http.MethodGet, "/v2/roles/bindings"); got != 0 {
+		t.Errorf("got %d role binding requests for a collection role, want none", got)
+	}
+}
+
+// bindRole binds a role to a directory entity on the server.
+func bindRole(srv *sactest.Server, roleId string, entity sac.DirectoryEntity) {
+	srv.Update(func(f *sactest.Fixtures) {
+		f.RoleBindings = append(f.RoleBindings, sac.RoleBinding{
+			ID:     "binding-" + entity.ID + "-" + roleId,
+			RoleID: roleId,
+			Entity: entity,
+		})
+	})
+}
+
+var (
+	bobEntity     = sac.DirectoryEntity{ID: "user-bob", IdentityProviderID: sactest.LocalIdentityProviderID, Type: user}
+	emptyGroup    = sac.Group{ID: "group-empty", Name: "Empty", RepositoryType: "local", IdentityProviderID: sactest.LocalIdentityProviderID}
+	emptyGroupRef = sac.DirectoryEntity{ID: emptyGroup.ID, IdentityProviderID: emptyGroup.IdentityProviderID, Type: group}
+)
+
+func TestRoleGrantAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	r := newRoleBuilder(srv.NewClient(), "")
+
+	viewer := testRole(t, "role-tenant-viewer")
+	assigned := testEntitlement(t, r, viewer)
+	bob := testUser(t, "user-bob")
+
+	if _, err := r.Grant(ctx, bob, assigned); err != nil {
+		t.Fatalf("granting: %v", err)
+	}
+	if bindings := roleBindings(srv, "role-tenant-viewer"); len(bindings) != 2 {
+		t.Fatalf("got %d bindings after the grant, want 2", len(bindings))
+	}
+
+	// Granting an existing binding does not create another one.
+	if _, err := r.Grant(ctx, bob, assigned); err != nil {
+		t.Fatalf("granting again: %v", err)
+	}
+	if got := srv.Requests(http.MethodPost, "/v2/roles/bindings"); got != 1 {
+		t.Errorf("got %d binding creations, want 1", got)
+	}
+
+	if _, err := r.Revoke(ctx, &v2.Grant{Entitlement: assigned, Principal: bob}); err != nil {
+		t.Fatalf("revoking: %v", err)
+	}
+	bindings := roleBindings(srv, "role-tenant-viewer")
+	if len(bindings) != 1 || bindings[0].Entity.ID != "group-engineering" {
+		t.Fatalf("got bindings %+v after the revoke, want the group-engineering binding", bindings)
+	}
+}
+
+func TestRoleGrantRejectsCollectionRoles(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	r := newRoleBuilder(srv.NewClient(), "")
+
+	// Collection roles have no entitlement; this one is built by hand, as a stale sync could hold.
+	collectionAdmin := testRole(t, "role-collection-admin")
+	assigned := &v2.Entitlement{Id: "role:role-collection-admin:assigned", Resource: collectionAdmin, Slug: assignmentEntitlement}
+
+	_, err := r.Grant(ctx, testUser(t, "user-bob"), assigned)
+	requireCode(t, err, codes.InvalidArgument)
+
+	_, err = r.Revoke(ctx, &v2.Grant{Entitlement: assigned, Principal: testUser(t, "user-carol")})
+	requireCode(t, err, codes.InvalidArgument)
+
+	if got := srv.Requests(http.MethodPost, "/v2/roles/bindings"); got != 0 {
+		t.Errorf("got %d binding creations, want none", got)
+	}
+}
+
+func TestRoleRevokeKeepsLastTenantAdmin(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	r := newRoleBuilder(srv.NewClient(), "")
+
+	admin := testRole(t, "role-tenant-admin")
+	assigned := testEntitlement(t, r, admin)
+	alice := &v2.Grant{Entitlement: assigned, Principal: testUser(t, "user-alice")}
+
+	_, err := r.Revoke(ctx, alice)
+	requireCode(t, err, codes.FailedPrecondition)
+	if bindings := roleBindings(srv, "role-tenant-admin"); len(bindings) != 1 {
+		t.Fatalf("got %d tenant admin bindings, want alice's binding kept", len(bindings))
+	}
+
+	bindRole(srv, "role-tenant-admin", bobEntity)
+
+	if _, err := r.Revoke(ctx, alice); err != nil {
+		t.Fatalf("revoking with another tenant admin: %v", err)
+	}
+	bindings := roleBindings(srv, "role-tenant-admin")
+	if len(bindings) != 1 || bindings[0].Entity.ID != "user-bob" {
+		t.Fatalf("got bindings %+v after the revoke, want bob's binding", bindings)
+	}
+}
+
+func TestRoleRevokeChecksTenantAdminsWithFreshData(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := srv.NewClient()
+	r := newRoleBuilder(client, "")
+
+	admin := testRole(t, "role-tenant-admin")
+	assigned := testEntitlement(t, r, admin)
+
+	// A sync caches bob's binding, then it is removed in the admin portal.
+	bindRole(srv, "role-tenant-admin", bobEntity)
+	if _, err := client.ListAllRoleBindings(ctx); err != nil {
+		t.Fatalf("listing role bindings: %v", err)
+	}
+	srv.Update(func(f *sactest.Fixtures) {
+		f.RoleBindings = f.RoleBindings[:len(f.RoleBindings)-1]
+	})
+
+	_, err := r.Revoke(ctx, &v2.Grant{Entitlement: assigned, Principal: testUser(t, "user-alice")})
+	requireCode(t, err, codes.FailedPrecondition)
+}
+
+func TestRoleRevokeCountsUsersOfBoundGroups(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	r := newRoleBuilder(srv.NewClient(), "")
+
+	admin := testRole(t, "role-tenant-admin")
+	assigned := testEntitlement(t, r, admin)
+	alice := &v2.Grant{Entitlement: assigned, Principal: testUser(t, "user-alice")}
+
+	srv.Update(func(f *sactest.Fixtures) {
+		f.Groups[sactest.LocalIdentityProviderID] = append(f.Groups[sactest.LocalIdentityProviderID], emptyGroup)
+	})
+	bindRole(srv, "role-tenant-admin", emptyGroupRef)
+
+	// A group without members leaves no tenant admin.
+	_, err := r.Revoke(ctx, alice)
+	requireCode(t, err, codes.FailedPrecondition)
+
+	srv.Update(func(f *sactest.Fixtures) {
+		f.GroupMembers[emptyGroup.ID] = []string{"user-bob"}
+	})
+
+	if _, err := r.Revoke(ctx, alice); err != nil {
+		t.Fatalf("revoking with a tenant admin group member: %v", err)
+	}
+}
+
+func TestRoleRevokeRestoresConcurrentlyRemovedLastAdmin(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	r := newRoleBuilder(srv.NewClient(), "")
+
+	admin := testRole(t, "role-tenant-admin")
+	assigned := testEntitlement(t, r, admin)
+
+	// The check before the delete still sees bob as a tenant admin, but his binding was removed in the admin portal.
+	srv.Inject(sactest.Fault{
+		Method: http.MethodGet,
+		Path:   "/v2/roles/bindings",
+		Status: http.StatusOK,
+		Times:  1,
+		Body: `{"content":[` +
+			`{"id":"binding-alice-admin","roleId":"role-tenant-admin","entity":{"id":"user-alice","type":"User"}},` +
+			`{"id":"binding-bob-admin","roleId":"role-tenant-admin","entity":{"id":"user-bob","type":"User"}}` +
+			`],"last":true}`,
+	})
+
+	_, err := r.Revoke(ctx, &v2.Grant{Entitlement: assigned, Principal: testUser(t, "user-alice")})
+	requireCode(t, err, codes.Aborted)
+
+	bindings := roleBindings(srv, "role-tenant-admin")
+	if len(bindings) != 1 || bindings[0].Entity.ID != "user-alice" {
+		t.Fatalf("got bindings %+v after the revoke, want alice's binding restored", bindings)
+	}
+	if got := srv.Requests(http.MethodDelete, "/v2/roles/bindings/binding-alice-admin"); got != 1 {
+		t.Errorf("got %d binding deletions, want 1", got)
+	}
+}
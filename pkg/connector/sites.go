@@ -0,0 +1,117 @@
+package connector
+
+import (
+	"context"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+type siteBuilder struct {
+	resourceType *v2.ResourceType
+	client       *sac.Client
+	portalURL    string
+}
+
+func (s *siteBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return s.resourceType
+}
+
+func siteResource(site *sac.Site, portalURL string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"site_id":                  site.ID,
+		"site_name":                site.Name,
+		"region":                   site.Region,
+		"connector_count":          len(site.Connectors),
+		"mute_health_notification": site.MuteHealthNotification,
+	}
+
+	appTraitOptions := []rs.AppTraitOption{rs.WithAppProfile(profile)}
+
+	resourceOptions := []rs.ResourceOption{
+		rs.WithParentResourceID(parentResourceID),
+		withPortalLink(portalURL, "sites", site.ID),
+		rs.WithAnnotation(
+			&v2.ChildResourceType{ResourceTypeId: connectorResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: applicationResourceType.Id},
+		),
+	}
+	if site.Description != "" {
+		resourceOptions = append(resourceOptions, rs.WithDescription(site.Description))
+	}
+
+	ret, err := rs.NewAppResource(
+		site.Name,
+		siteResourceType,
+		site.ID,
+		appTraitOptions,
+		resourceOptions...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (s *siteBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	pageNumber, err := parsePageNumber(pToken.Token)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	sites, paginationData, err := s.client.ListSites(ctx, pageNumber)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list sites")
+	}
+
+	token, err := nextPageNumberToken(pageNumber, len(sites), paginationData)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	collections, err := collectionOf(ctx, s.client)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list collections")
+	}
+
+	var rv []*v2.Resource
+	for _, site := range sites {
+		// Sites are listed under their collection, and under the account only when they are not in one.
+		if !inCollection(parentResourceID, collections[site.ID]) {
+			continue
+		}
+
+		siteCopy := site
+		sr, err := siteResource(&siteCopy, s.portalURL, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		rv = append(rv, sr)
+	}
+
+	return rv, token, rateLimitAnnotations(s.client), nil
+}
+
+func (s *siteBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func (s *siteBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func newSiteBuilder(client *sac.Client, portalURL string) *siteBuilder {
+	return &siteBuilder{
+		resourceType: siteResourceType,
+		client:       client,
+		portalURL:    portalURL,
+	}
+}
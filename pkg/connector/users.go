@@ -2,18 +2,28 @@ package connector
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	sac "github.com/conductorone/baton-broadcom-sac/pkg/sac"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type userBuilder struct {
 	resourceType *v2.ResourceType
 	client       *sac.Client
+	portalURL    string
+	// enrichConcurrency is how many user detail records are fetched at once. Zero disables enrichment.
+	enrichConcurrency int
 }
 
 func (u *userBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
@@ -57,13 +67,16 @@ func baseUserResource(entity *sac.DirectoryEntity, parentResourceID *v2.Resource
 	return ret, nil
 }
 
-func userResource(user *sac.User, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+// userResource returns the resource for a user. details is the user's detail record, nil when users are not enriched.
+func userResource(user *sac.User, details *sac.UserDetails, portalURL string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
 	profile := map[string]interface{}{
-		"first_name":        valOrFallback(user.FirstName, user.Username),
-		"last_name":         valOrFallback(user.LastName, ""),
-		"login":             user.Email,
-		"user_id":           user.ID,
-		"identity_provider": user.RepositoryType,
+		"first_name":             valOrFallback(user.FirstName, user.Username),
+		"last_name":              valOrFallback(user.LastName, ""),
+		"login":                  user.Email,
+		"user_id":                user.ID,
+		"identity_provider":      user.RepositoryType,
+		"identity_provider_id":   user.IdentityProviderID,
+		"identifier_in_provider": valOrFallback(user.IdentifierInProvider, user.ID),
 	}
 
 	var userStatus v2.UserTrait_Status_Status
@@ -77,17 +90,39 @@ func userResource(user *sac.User, parentResourceID *v2.ResourceId) (*v2.Resource
 	}
 
 	userTraitOptions := []rs.UserTraitOption{
-		rs.WithUserProfile(profile),
 		rs.WithEmail(user.Email, true),
 		rs.WithStatus(userStatus),
+		rs.WithUserLogin(user.Username),
 	}
 
+	if details != nil {
+		profile["mfa_enrolled"] = details.MFAEnrolled
+		profile["identity_provider_name"] = details.IdentityProviderName
+		profile["phone"] = details.PhoneNumber
+		profile["notification_email"] = details.NotificationEmail
+
+		if createdAt, ok := parseTimestamp(details.CreatedOn); ok {
+			profile["created_at"] = createdAt.Format(time.RFC3339)
+			userTraitOptions = append(userTraitOptions, rs.WithCreatedAt(createdAt))
+		}
+
+		if lastLogin, ok := parseTimestamp(details.LastLogin); ok {
+			profile["last_login"] = lastLogin.Format(time.RFC3339)
+			userTraitOptions = append(userTraitOptions, rs.WithLastLogin(lastLogin))
+		}
+
+		userTraitOptions = append(userTraitOptions, rs.WithMFAStatus(&v2.UserTrait_MFAStatus{MfaEnabled: details.MFAEnrolled}))
+	}
+
+	userTraitOptions = append(userTraitOptions, rs.WithUserProfile(profile))
+
 	ret, err := rs.NewUserResource(
 		user.Username,
 		userResourceType,
 		user.ID,
 		userTraitOptions,
 		rs.WithParentResourceID(parentResourceID),
+		withPortalLink(portalURL, "identities", user.IdentityProviderID, "users", user.ID),
 	)
 
 	if err != nil {
@@ -97,27 +132,41 @@ func userResource(user *sac.User, parentResourceID *v2.ResourceId) (*v2.Resource
 	return ret, nil
 }
 
-func (u *userBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+func (u *userBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
 	if parentResourceID == nil {
 		return nil, "", nil, nil
 	}
 
-	var rv []*v2.Resource
-	users, err := u.client.ListAllUsers(ctx)
+	if parentResourceID.ResourceType != identityProviderResourceType.Id {
+		return nil, "", nil, nil
+	}
+
+	users, paginationData, err := u.client.ListUsersPerProvider(ctx, parentResourceID.Resource, pToken.Token)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list users")
+	}
+
+	token, err := nextOffsetPageToken(pToken.Token, paginationData)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	for _, user := range users {
+	details, err := u.userDetails(ctx, parentResourceID.Resource, users)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to get user details")
+	}
+
+	var rv []*v2.Resource
+	for i, user := range users {
 		userCopy := user
-		ur, err := userResource(&userCopy, parentResourceID)
+		ur, err := userResource(&userCopy, details[i], u.portalURL, parentResourceID)
 		if err != nil {
 			return nil, "", nil, err
 		}
 		rv = append(rv, ur)
 	}
 
-	return rv, "", nil, nil
+	return rv, token, rateLimitAnnotations(u.client), nil
 }
 
 func (o *userBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
@@ -128,9 +177,222 @@ func (o *userBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.To
 	return nil, "", nil, nil
 }
 
-func newUserBuilder(client *sac.Client) *userBuilder {
+// userDetails fetches the detail records of users, at most enrichConcurrency at a time. The records are returned
+// in the order of users; all of them are nil when enrichment is disabled. Users deleted since they were listed get
+// no record. The first failure cancels the fetches still running and those not started yet.
+func (u *userBuilder) userDetails(ctx context.Context, identityProviderId string, users []sac.User) ([]*sac.UserDetails, error) {
+	rv := make([]*sac.UserDetails, len(users))
+	if u.enrichConcurrency <= 0 {
+		return rv, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mtx      sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, u.enrichConcurrency)
+
+	for i, user := range users {
+		iCopy, userCopy := i, user
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			details, err := u.client.GetUser(ctx, identityProviderId, userCopy.ID)
+			if err != nil {
+				if sac.IsNotFound(err) {
+					return
+				}
+
+				mtx.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mtx.Unlock()
+				return
+			}
+
+			rv[iCopy] = &details
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	// Fetches skipped because the caller's context ended leave no error behind.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+// createLocalUser creates a user in the local identity provider and adds it to groups, given by ID, of that
+// identity provider. The groups are checked before the user is created, and the user is deleted again when it
+// cannot join one of them, so the create can be retried.
+func (u *userBuilder) createLocalUser(ctx context.Context, user sac.LocalUser, groupIds []string) (*v2.Resource, error) {
+	ctx = sac.WithoutCache(ctx)
+
+	if user.Username == "" || user.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "baton-broadcom-sac: a username and an email are required to create a user")
+	}
+
+	identityProvider, err := findLocalIdentityProvider(ctx, u.client)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(groupIds) > 0 {
+		groups, err := u.client.GroupsPager(identityProvider.ID, 0).All(ctx)
+		if err != nil {
+			return nil, wrapError(err, "failed to list groups")
+		}
+
+		for _, groupId := range groupIds {
+			if !hasGroup(groups, groupId) {
+				return nil, status.Errorf(codes.NotFound, "baton-broadcom-sac: group %s not found in the %s identity provider", groupId, identityProvider.Name)
+			}
+		}
+	}
+
+	created, err := u.client.CreateLocalUser(ctx, identityProvider.ID, user)
+	if err != nil {
+		return nil, wrapError(err, "failed to create user")
+	}
+
+	for _, groupId := range groupIds {
+		err := u.client.AddGroupMember(ctx, identityProvider.ID, groupId, created.ID)
+		if err != nil && !sac.IsConflict(err) {
+			if deleteErr := u.client.DeleteUser(ctx, identityProvider.ID, created.ID); deleteErr != nil && !sac.IsNotFound(deleteErr) {
+				return nil, wrapError(err, fmt.Sprintf("user %s was created but failed to join group %s, and deleting it failed: %s; delete it before retrying",
+					created.ID, groupId, deleteErr))
+			}
+			return nil, wrapError(err, fmt.Sprintf("failed to add the new user to group %s, the user was deleted", groupId))
+		}
+	}
+
+	return userResource(&created, nil, u.portalURL, &v2.ResourceId{ResourceType: identityProviderResourceType.Id, Resource: identityProvider.ID})
+}
+
+// setLocalUserBlocked blocks or unblocks a user of the local identity provider.
+func (u *userBuilder) setLocalUserBlocked(ctx context.Context, userId string, blocked bool) error {
+	ctx = sac.WithoutCache(ctx)
+
+	identityProvider, err := findLocalIdentityProvider(ctx, u.client)
+	if err != nil {
+		return err
+	}
+
+	if err := u.client.SetUserBlocked(ctx, identityProvider.ID, userId, blocked); err != nil {
+		return wrapError(err, "failed to change user blocked status")
+	}
+
+	return nil
+}
+
+// deleteLocalUser deletes a user of the local identity provider.
+func (u *userBuilder) deleteLocalUser(ctx context.Context, userId string) error {
+	ctx = sac.WithoutCache(ctx)
+
+	identityProvider, err := findLocalIdentityProvider(ctx, u.client)
+	if err != nil {
+		return err
+	}
+
+	if err := u.client.DeleteUser(ctx, identityProvider.ID, userId); err != nil {
+		return wrapError(err, "failed to delete user")
+	}
+
+	return nil
+}
+
+// CreateAccount creates a user in the local identity provider. The login is the username and the primary email,
+// or the first one, the user's email; first_name, last_name and notification_email are read from the profile.
+func (u *userBuilder) CreateAccount(
+	ctx context.Context,
+	accountInfo *v2.AccountInfo,
+	_ *v2.CredentialOptions,
+) (connectorbuilder.CreateAccountResponse, []*v2.PlaintextData, annotations.Annotations, error) {
+	user := localUserFromProfile(accountInfo.GetLogin(), accountInfo.GetProfile())
+	for _, email := range accountInfo.GetEmails() {
+		if user.Email == "" || email.GetIsPrimary() {
+			user.Email = email.GetAddress()
+		}
+	}
+
+	resource, err := u.createLocalUser(ctx, user, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &v2.CreateAccountResponse_SuccessResult{Resource: resource}, nil, rateLimitAnnotations(u.client), nil
+}
+
+// Create creates a user in the local identity provider from the login, email and profile of its user trait.
+func (u *userBuilder) Create(ctx context.Context, resource *v2.Resource) (*v2.Resource, annotations.Annotations, error) {
+	trait, err := rs.GetUserTrait(resource)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "baton-broadcom-sac: user resource has no user trait: %s", err)
+	}
+
+	user := localUserFromProfile(trait.GetLogin(), trait.GetProfile())
+	for _, email := range trait.GetEmails() {
+		if user.Email == "" || email.GetIsPrimary() {
+			user.Email = email.GetAddress()
+		}
+	}
+
+	created, err := u.createLocalUser(ctx, user, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return created, rateLimitAnnotations(u.client), nil
+}
+
+// Delete deletes a user of the local identity provider.
+func (u *userBuilder) Delete(ctx context.Context, resourceId *v2.ResourceId) (annotations.Annotations, error) {
+	if err := u.deleteLocalUser(ctx, resourceId.Resource); err != nil {
+		return nil, err
+	}
+
+	return rateLimitAnnotations(u.client), nil
+}
+
+// localUserFromProfile returns the user to create for a login and the profile of an account or user trait.
+func localUserFromProfile(login string, profile *structpb.Struct) sac.LocalUser {
+	user := sac.LocalUser{Username: login}
+	user.FirstName, _ = rs.GetProfileStringValue(profile, "first_name")
+	user.LastName, _ = rs.GetProfileStringValue(profile, "last_name")
+	user.NotificationEmail, _ = rs.GetProfileStringValue(profile, "notification_email")
+	return user
+}
+
+func newUserBuilder(client *sac.Client, portalURL string, enrichConcurrency int) *userBuilder {
 	return &userBuilder{
-		resourceType: userResourceType,
-		client:       client,
+		resourceType:      userResourceType,
+		client:            client,
+		portalURL:         portalURL,
+		enrichConcurrency: enrichConcurrency,
 	}
 }
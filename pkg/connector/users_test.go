@@ -0,0 +1,244 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestUserListPagesEachIdentityProvider(t *testing.T) {
+	srv := newTestServer(t)
+	u := newUserBuilder(srv.NewClient(sac.WithPageSize(1)), "", 0)
+
+	local, calls := listAll(t, u, identityProviderID(sactest.LocalIdentityProviderID))
+	if got := strings.Join(resourceIDs(local), ","); got != "user-alice,user-bob" {
+		t.Errorf("got local users %s, want user-alice,user-bob", got)
+	}
+	if calls != 2 {
+		t.Errorf("listed local users in %d calls, want one per page", calls)
+	}
+
+	// The page token of one identity provider is not carried over to the next.
+	okta, _ := listAll(t, u, identityProviderID(sactest.OktaIdentityProviderID))
+	if got := strings.Join(resourceIDs(okta), ","); got != "user-carol" {
+		t.Errorf("got Okta users %s, want user-carol", got)
+	}
+	for _, ur := range okta {
+		if ur.ParentResourceId.Resource != sactest.OktaIdentityProviderID {
+			t.Errorf("user %s has parent %s, want %s", ur.Id.Resource, ur.ParentResourceId.Resource, sactest.OktaIdentityProviderID)
+		}
+	}
+
+	path := "/v2/identities/" + sactest.LocalIdentityProviderID + "/users"
+	if got := srv.Requests(http.MethodGet, path); got != 2 {
+		t.Errorf("got %d requests for %s, want 2", got, path)
+	}
+}
+
+func TestUserListSkipsOtherParents(t *testing.T) {
+	srv := newTestServer(t)
+	u := newUserBuilder(srv.NewClient(), "", 0)
+
+	resources, token, _, err := u.List(context.Background(), nil, &pagination.Token{})
+	if err != nil || len(resources) != 0 || token != "" {
+		t.Errorf("got %d users, token %q and error %v without a parent, want nothing", len(resources), token, err)
+	}
+}
+
+func TestUserListEnrichesUsers(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Inject(sactest.Fault{Method: http.MethodGet, Path: "/v2/identities/" + sactest.LocalIdentityProviderID + "/users/user-bob", Status: http.StatusNotFound})
+	u := newUserBuilder(srv.NewClient(), "", 2)
+
+	users, _ := listAll(t, u, identityProviderID(sactest.LocalIdentityProviderID))
+	if len(users) != 2 {
+		t.Fatalf("got users %v, want alice and bob", resourceIDs(users))
+	}
+
+	alice, err := rs.GetUserTrait(users[0])
+	if err != nil {
+		t.Fatalf("reading the user trait: %v", err)
+	}
+	if !alice.GetMfaStatus().GetMfaEnabled() || alice.GetLastLogin() == nil {
+		t.Errorf("got user trait %v, want alice's MFA enrollment and last login", alice)
+	}
+
+	// Bob was deleted after he was listed, so he is synced without his detail record.
+	bob, err := rs.GetUserTrait(users[1])
+	if err != nil {
+		t.Fatalf("reading the user trait: %v", err)
+	}
+	if bob.GetLastLogin() != nil || bob.GetMfaStatus() != nil {
+		t.Errorf("got user trait %v, want no details for bob", bob)
+	}
+}
+
+func TestUserListStopsEnrichingOnFirstError(t *testing.T) {
+	srv := newTestServer(t)
+	var ids []string
+	srv.Update(func(f *sactest.Fixtures) {
+		for i := 0; i < 10; i++ {
+			id := fmt.Sprintf("user-%d", i)
+			f.Users[sactest.LocalIdentityProviderID] = append(f.Users[sactest.LocalIdentityProviderID],
+				sac.User{ID: id, Username: id, RepositoryType: "local", IdentityProviderID: sactest.LocalIdentityProviderID})
+		}
+		for _, user := range f.Users[sactest.LocalIdentityProviderID] {
+			ids = append(ids, user.ID)
+		}
+	})
+	srv.Inject(sactest.Fault{Method: http.MethodGet, Path: "/v2/identities/" + sactest.LocalIdentityProviderID + "/users/", Status: http.StatusBadRequest})
+	u := newUserBuilder(srv.NewClient(sac.WithRetryPolicy(sac.RetryPolicy{MaxAttempts: 1})), "", 1)
+
+	_, _, _, err := u.List(context.Background(), identityProviderID(sactest.LocalIdentityProviderID), &pagination.Token{})
+	requireCode(t, err, codes.InvalidArgument)
+
+	requests := 0
+	for _, id := range ids {
+		requests += srv.Requests(http.MethodGet, "/v2/identities/"+sactest.LocalIdentityProviderID+"/users/"+id)
+	}
+	if requests != 1 {
+		t.Errorf("got %d detail requests, want the first failure to cancel the others", requests)
+	}
+}
+
+// localUser returns the user of the local identity provider with the given username as stored by the server.
+func localUser(srv *sactest.Server, username string) (sac.User, bool) {
+	var rv sac.User
+	found := false
+	srv.Update(func(f *sactest.Fixtures) {
+		for _, u := range f.Users[sactest.LocalIdentityProviderID] {
+			if u.Username == username {
+				rv, found = u, true
+			}
+		}
+	})
+	return rv, found
+}
+
+func TestUserCreateAccount(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	u := newUserBuilder(srv.NewClient(), "", 0)
+
+	profile, err := structpb.NewStruct(map[string]interface{}{"first_name": "Dave", "last_name": "Davis"})
+	if err != nil {
+		t.Fatalf("building profile: %v", err)
+	}
+	res, _, _, err := u.CreateAccount(ctx, &v2.AccountInfo{
+		Login:   "dave",
+		Emails:  []*v2.AccountInfo_Email{{Address: "dave@example.com", IsPrimary: true}},
+		Profile: profile,
+	}, nil)
+	if err != nil {
+		t.Fatalf("creating account: %v", err)
+	}
+
+	created, ok := localUser(srv, "dave")
+	if !ok {
+		t.Fatal("dave was not created")
+	}
+	if created.Email != "dave@example.com" || created.FirstName != "Dave" || created.LastName != "Davis" {
+		t.Errorf("got user %+v, want dave's email and names", created)
+	}
+
+	success, ok := res.(*v2.CreateAccountResponse_SuccessResult)
+	if !ok || success.Resource.Id.Resource != created.ID {
+		t.Errorf("got result %v, want the resource of %s", res, created.ID)
+	}
+	if parent := success.Resource.ParentResourceId; parent.Resource != sactest.LocalIdentityProviderID {
+		t.Errorf("got parent %v, want the local identity provider", parent)
+	}
+}
+
+func TestUserCreateJoinsGroups(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	u := newUserBuilder(srv.NewClient(), "", 0)
+
+	ur, err := u.createLocalUser(ctx, sac.LocalUser{Username: "dave", Email: "dave@example.com"}, []string{"group-admins"})
+	if err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	if !contains(groupMembers(srv, "group-admins"), ur.Id.Resource) {
+		t.Error("dave did not join group-admins")
+	}
+
+	// Unknown groups are rejected before the user is created.
+	_, err = u.createLocalUser(ctx, sac.LocalUser{Username: "erin", Email: "erin@example.com"}, []string{"group-missing"})
+	requireCode(t, err, codes.NotFound)
+	if _, ok := localUser(srv, "erin"); ok {
+		t.Error("erin was created despite the unknown group")
+	}
+
+	_, err = u.createLocalUser(ctx, sac.LocalUser{Username: "frank"}, nil)
+	requireCode(t, err, codes.InvalidArgument)
+}
+
+func TestUserCreateDeletesUsersThatCannotJoinGroups(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	srv.Inject(sactest.Fault{Method: http.MethodPut, Path: "/v2/identities/" + sactest.LocalIdentityProviderID + "/groups/group-admins/users/", Status: http.StatusForbidden})
+	u := newUserBuilder(srv.NewClient(), "", 0)
+
+	_, err := u.createLocalUser(ctx, sac.LocalUser{Username: "dave", Email: "dave@example.com"}, []string{"group-admins"})
+	requireCode(t, err, codes.PermissionDenied)
+	if !strings.Contains(err.Error(), "the user was deleted") {
+		t.Errorf("got error %v, want it to say the user was deleted", err)
+	}
+	if _, ok := localUser(srv, "dave"); ok {
+		t.Error("dave was kept after failing to join group-admins")
+	}
+
+	// When the user cannot be deleted either, the error says it is left behind.
+	srv.Inject(sactest.Fault{Method: http.MethodDelete, Path: "/v2/identities/" + sactest.LocalIdentityProviderID + "/users/", Status: http.StatusForbidden})
+
+	_, err = u.createLocalUser(ctx, sac.LocalUser{Username: "dave", Email: "dave@example.com"}, []string{"group-admins"})
+	requireCode(t, err, codes.PermissionDenied)
+	created, ok := localUser(srv, "dave")
+	if !ok {
+		t.Fatal("dave was not created")
+	}
+	if !strings.Contains(err.Error(), created.ID+" was created but failed to join group group-admins, and deleting it failed") {
+		t.Errorf("got error %v, want it to name the user left behind", err)
+	}
+}
+
+func TestUserBlockAndDelete(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	u := newUserBuilder(srv.NewClient(), "", 0)
+
+	if err := u.setLocalUserBlocked(ctx, "user-alice", true); err != nil {
+		t.Fatalf("blocking: %v", err)
+	}
+	if alice, _ := localUser(srv, "alice"); !alice.Blocked {
+		t.Error("alice is not blocked")
+	}
+
+	if err := u.setLocalUserBlocked(ctx, "user-bob", false); err != nil {
+		t.Fatalf("unblocking: %v", err)
+	}
+	if bob, _ := localUser(srv, "bob"); bob.Blocked {
+		t.Error("bob is still blocked")
+	}
+
+	if _, err := u.Delete(ctx, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "user-bob"}); err != nil {
+		t.Fatalf("deleting: %v", err)
+	}
+	if _, ok := localUser(srv, "bob"); ok {
+		t.Error("bob was not deleted")
+	}
+
+	_, err := u.Delete(ctx, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "user-bob"})
+	requireCode(t, err, codes.NotFound)
+}
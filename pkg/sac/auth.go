@@ -0,0 +1,124 @@
+package sac
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin is how long before the reported expiry a token is considered stale and refreshed.
+const tokenRefreshMargin = 2 * time.Minute
+
+type AuthResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Scope            string `json:"scope"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// TokenSource issues bearer tokens for the SAC API and refreshes them before they expire.
+// It is safe for concurrent use.
+type TokenSource struct {
+	httpClient   *http.Client
+	authURL      string
+	clientID     string
+	clientSecret string
+
+	mtx       sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+func NewTokenSource(httpClient *http.Client, authURL, clientID, clientSecret string) *TokenSource {
+	return &TokenSource{
+		httpClient:   httpClient,
+		authURL:      authURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+	}
+}
+
+// Token returns a valid bearer token, requesting a new one when there is no cached token or it is about to expire.
+func (ts *TokenSource) Token(ctx context.Context) (string, error) {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+
+	if ts.token != "" && (ts.refreshAt.IsZero() || time.Now().Before(ts.refreshAt)) {
+		return ts.token, nil
+	}
+
+	res, err := requestToken(ctx, ts.httpClient, ts.authURL, ts.clientID, ts.clientSecret)
+	if err != nil {
+		return "", err
+	}
+
+	ts.token = res.AccessToken
+	ts.refreshAt = refreshDeadline(time.Now(), res.ExpiresIn)
+
+	return ts.token, nil
+}
+
+// Invalidate drops the cached token so the next call to Token re-authenticates.
+// The token is only dropped if it is still the one passed in, so a token refreshed by a concurrent request is kept.
+func (ts *TokenSource) Invalidate(token string) {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+
+	if ts.token == token {
+		ts.token = ""
+		ts.refreshAt = time.Time{}
+	}
+}
+
+// refreshDeadline returns the time after which a token issued at issuedAt should be refreshed.
+// A zero time means the expiry is unknown and the token is kept until it is rejected.
+func refreshDeadline(issuedAt time.Time, expiresIn int) time.Time {
+	if expiresIn <= 0 {
+		return time.Time{}
+	}
+
+	lifetime := time.Duration(expiresIn) * time.Second
+	margin := tokenRefreshMargin
+	if margin > lifetime/2 {
+		margin = lifetime / 2
+	}
+
+	return issuedAt.Add(lifetime - margin)
+}
+
+func requestToken(ctx context.Context, httpClient *http.Client, authURL, username, password string) (*AuthResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, authURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Accept", applicationJSONHeader)
+	req.Header.Add("Content-Type", applicationJSONHeader)
+	req.SetBasicAuth(username, password)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newAPIError(resp)
+	}
+
+	var res AuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+
+	if res.Error != "" {
+		return nil, fmt.Errorf("error creating bearer token. %s: %s", res.Error, res.ErrorDescription)
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,82 @@
+package sac_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+)
+
+func TestClientReauthenticatesOnUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := srv.NewClient(sac.WithRetryPolicy(testRetryPolicy))
+
+	if _, err := client.ListAllPolicies(ctx); err != nil {
+		t.Fatalf("listing policies: %v", err)
+	}
+
+	srv.ExpireTokens()
+	client.ResetCache(ctx)
+
+	policies, err := client.ListAllPolicies(ctx)
+	if err != nil {
+		t.Fatalf("listing policies after the token expired: %v", err)
+	}
+	if len(policies) != 2 {
+		t.Errorf("got %d policies, want 2", len(policies))
+	}
+
+	if got := srv.Requests(http.MethodPost, "/v1/oauth/token"); got != 2 {
+		t.Errorf("got %d token requests, want 2", got)
+	}
+}
+
+func TestClientFailsWhenReauthenticationIsRejected(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	srv.Inject(sactest.Fault{Path: policiesPath, Status: http.StatusUnauthorized})
+	client := srv.NewClient(sac.WithRetryPolicy(testRetryPolicy))
+
+	_, err := client.ListAllPolicies(ctx)
+	if !sac.IsUnauthorized(err) {
+		t.Fatalf("got error %v, want unauthorized", err)
+	}
+
+	if got := srv.Requests(http.MethodGet, policiesPath); got != 2 {
+		t.Errorf("got %d policy requests, want 2", got)
+	}
+}
+
+func TestTokenSourceReusesTokens(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	tokenSource := sac.NewTokenSource(srv.Client(), sac.TokenURL(srv.URL), sactest.ClientID, sactest.ClientSecret)
+
+	first, err := tokenSource.Token(ctx)
+	if err != nil {
+		t.Fatalf("getting token: %v", err)
+	}
+	second, err := tokenSource.Token(ctx)
+	if err != nil {
+		t.Fatalf("getting token: %v", err)
+	}
+	if first != second {
+		t.Error("got a new token before the first one expired")
+	}
+
+	tokenSource.Invalidate(first)
+
+	third, err := tokenSource.Token(ctx)
+	if err != nil {
+		t.Fatalf("getting token after invalidating it: %v", err)
+	}
+	if third == first {
+		t.Error("got the invalidated token again")
+	}
+	if got := srv.Requests(http.MethodPost, "/v1/oauth/token"); got != 2 {
+		t.Errorf("got %d token requests, want 2", got)
+	}
+}
@@ -0,0 +1,325 @@
+package sac
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheOptions configures the response cache shared by all requests of a Client.
+type CacheOptions struct {
+	// TTL is how long a cached response is served. Zero disables caching.
+	TTL time.Duration
+	// MaxBytes caps the size of the response bodies kept in memory. Least recently used responses are evicted first.
+	MaxBytes int64
+	// Dir, when set, is a directory evicted responses are spilled to instead of being dropped.
+	Dir string
+}
+
+// DefaultCacheOptions returns the cache options used when none are configured.
+func DefaultCacheOptions() CacheOptions {
+	return CacheOptions{
+		TTL:      time.Hour,
+		MaxBytes: 256 * 1024 * 1024,
+	}
+}
+
+// CacheStats reports how effective the response cache has been since it was last reset.
+type CacheStats struct {
+	Hits     int64
+	Misses   int64
+	Entries  int
+	Bytes    int64
+	OnDisk   int
+	Evicted  int64
+	Disabled bool
+}
+
+// HitRate returns the share of lookups served from the cache.
+func (s CacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+type uncachedKey struct{}
+
+// WithoutCache returns a context whose requests bypass the response cache: they are always sent to the API and
+// their responses are not cached. Changes are made with it, so the checks preceding a change see the current
+// state of the tenant instead of the state of the last sync.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, uncachedKey{}, true)
+}
+
+func isUncached(ctx context.Context) bool {
+	uncached, _ := ctx.Value(uncachedKey{}).(bool)
+	return uncached
+}
+
+// responseCache caches GET response bodies keyed by endpoint and query. It is scoped to a sync:
+// the client resets it whenever a new sync starts.
+type responseCache struct {
+	opts CacheOptions
+
+	mtx     sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	bytes   int64
+	disk    map[string]diskEntry
+	diskDir string
+	hits    int64
+	misses  int64
+	evicted int64
+}
+
+type cacheEntry struct {
+	key       string
+	body      []byte
+	expiresAt time.Time
+}
+
+type diskEntry struct {
+	path      string
+	expiresAt time.Time
+}
+
+func newResponseCache(opts CacheOptions) *responseCache {
+	return &responseCache{
+		opts:    opts,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		disk:    make(map[string]diskEntry),
+	}
+}
+
+func (c *responseCache) enabled() bool {
+	return c != nil && c.opts.TTL > 0
+}
+
+func (c *responseCache) get(key string) ([]byte, bool) {
+	if !c.enabled() {
+		return nil, false
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := time.Now()
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*cacheEntry)
+		if now.Before(entry.expiresAt) {
+			c.lru.MoveToFront(el)
+			c.hits++
+			return entry.body, true
+		}
+		c.removeElement(el)
+	}
+
+	if de, ok := c.disk[key]; ok {
+		if now.Before(de.expiresAt) {
+			body, err := os.ReadFile(de.path)
+			if err == nil {
+				c.hits++
+				return body, true
+			}
+		}
+		c.removeDisk(key)
+	}
+
+	c.misses++
+	return nil, false
+}
+
+func (c *responseCache) set(key string, body []byte) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.removeElement(el)
+	}
+	c.removeDisk(key)
+
+	entry := &cacheEntry{
+		key:       key,
+		body:      body,
+		expiresAt: time.Now().Add(c.opts.TTL),
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.bytes += int64(len(body))
+
+	for c.opts.MaxBytes > 0 && c.bytes > c.opts.MaxBytes && c.lru.Len() > 0 {
+		oldest := c.lru.Back()
+		c.spill(oldest.Value.(*cacheEntry))
+		c.removeElement(oldest)
+		c.evicted++
+	}
+}
+
+// invalidate drops every cached response whose key starts with prefix.
+func (c *responseCache) invalidate(prefix string) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for key, el := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(el)
+		}
+	}
+
+	for key := range c.disk {
+		if strings.HasPrefix(key, prefix) {
+			c.removeDisk(key)
+		}
+	}
+}
+
+// reset drops every cached response and returns the statistics collected since the previous reset.
+func (c *responseCache) reset() CacheStats {
+	if !c.enabled() {
+		return CacheStats{Disabled: true}
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	stats := c.statsLocked()
+
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.bytes = 0
+	c.disk = make(map[string]diskEntry)
+	_ = c.removeDiskDir()
+	c.hits = 0
+	c.misses = 0
+	c.evicted = 0
+
+	return stats
+}
+
+// close removes the directory evicted responses were spilled to. Responses evicted afterwards are spilled to a new one.
+func (c *responseCache) close() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.disk = make(map[string]diskEntry)
+	return c.removeDiskDir()
+}
+
+func (c *responseCache) removeDiskDir() error {
+	if c.diskDir == "" {
+		return nil
+	}
+
+	err := os.RemoveAll(c.diskDir)
+	c.diskDir = ""
+	return err
+}
+
+func (c *responseCache) stats() CacheStats {
+	if !c.enabled() {
+		return CacheStats{Disabled: true}
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.statsLocked()
+}
+
+func (c *responseCache) statsLocked() CacheStats {
+	return CacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: len(c.entries),
+		Bytes:   c.bytes,
+		OnDisk:  len(c.disk),
+		Evicted: c.evicted,
+	}
+}
+
+func (c *responseCache) removeElement(el *list.Element) {
+	entry := el.Value.(*cacheEntry)
+	c.lru.Remove(el)
+	delete(c.entries, entry.key)
+	c.bytes -= int64(len(entry.body))
+}
+
+func (c *responseCache) removeDisk(key string) {
+	de, ok := c.disk[key]
+	if !ok {
+		return
+	}
+
+	_ = os.Remove(de.path)
+	delete(c.disk, key)
+}
+
+// spill writes an entry evicted from memory to the disk tier, if one is configured.
+// Failing to write only means the response is fetched again.
+func (c *responseCache) spill(entry *cacheEntry) {
+	if c.opts.Dir == "" {
+		return
+	}
+
+	if c.diskDir == "" {
+		c.removeStaleSpillDirs()
+
+		dir, err := os.MkdirTemp(c.opts.Dir, spillDirPrefix)
+		if err != nil {
+			return
+		}
+		c.diskDir = dir
+	}
+
+	sum := sha256.Sum256([]byte(entry.key))
+	path := filepath.Join(c.diskDir, hex.EncodeToString(sum[:]))
+	if err := os.WriteFile(path, entry.body, 0600); err != nil {
+		return
+	}
+
+	c.disk[entry.key] = diskEntry{
+		path:      path,
+		expiresAt: entry.expiresAt,
+	}
+}
+
+// spillDirPrefix prefixes the name of the directories responses are spilled to.
+const spillDirPrefix = "baton-broadcom-sac-cache-"
+
+// removeStaleSpillDirs removes the spill directories left behind by connectors that exited without closing their
+// client, e.g. because they were killed mid-sync. A directory nothing was written to for longer than the TTL only
+// holds expired responses, so removing it is safe even if its process is still running.
+func (c *responseCache) removeStaleSpillDirs() {
+	dirs, err := filepath.Glob(filepath.Join(c.opts.Dir, spillDirPrefix+"*"))
+	if err != nil {
+		return
+	}
+
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() || time.Since(info.ModTime()) <= c.opts.TTL {
+			continue
+		}
+		_ = os.RemoveAll(dir)
+	}
+}
@@ -0,0 +1,176 @@
+package sac_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+)
+
+func TestClientCachesUntilReset(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := srv.NewClient()
+
+	if _, err := client.ListRoles(ctx); err != nil {
+		t.Fatalf("listing roles: %v", err)
+	}
+
+	srv.Update(func(f *sactest.Fixtures) {
+		f.Roles = f.Roles[:1]
+	})
+
+	roles, err := client.ListRoles(ctx)
+	if err != nil {
+		t.Fatalf("listing roles: %v", err)
+	}
+	if len(roles) != 3 {
+		t.Errorf("got %d cached roles, want 3", len(roles))
+	}
+	if got := srv.Requests(http.MethodGet, "/v2/roles"); got != 1 {
+		t.Errorf("got %d role requests, want 1", got)
+	}
+
+	roles, err = client.ListRoles(sac.WithoutCache(ctx))
+	if err != nil {
+		t.Fatalf("listing roles without the cache: %v", err)
+	}
+	if len(roles) != 1 {
+		t.Errorf("got %d uncached roles, want 1", len(roles))
+	}
+
+	client.ResetCache(ctx)
+
+	roles, err = client.ListRoles(ctx)
+	if err != nil {
+		t.Fatalf("listing roles after a reset: %v", err)
+	}
+	if len(roles) != 1 {
+		t.Errorf("got %d roles after a reset, want 1", len(roles))
+	}
+	if stats := client.CacheStats(); stats.Hits != 0 || stats.Misses != 1 {
+		t.Errorf("got %d hits and %d misses after a reset, want 0 and 1", stats.Hits, stats.Misses)
+	}
+}
+
+func TestClientInvalidatesPoliciesOnUpdate(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := srv.NewClient()
+
+	policy, err := client.GetPolicy(ctx, "policy-ssh")
+	if err != nil {
+		t.Fatalf("getting policy: %v", err)
+	}
+	if len(policy.DirectoryEntities) != 1 {
+		t.Fatalf("got %d directory entities, want 1", len(policy.DirectoryEntities))
+	}
+
+	err = client.ModifyPolicyDirectoryEntities(ctx, "policy-ssh", func(entities []sac.DirectoryEntity) ([]sac.DirectoryEntity, bool) {
+		return nil, true
+	})
+	if err != nil {
+		t.Fatalf("modifying policy: %v", err)
+	}
+
+	policy, err = client.GetPolicy(ctx, "policy-ssh")
+	if err != nil {
+		t.Fatalf("getting policy: %v", err)
+	}
+	if len(policy.DirectoryEntities) != 0 {
+		t.Errorf("got %d directory entities after the update, want 0", len(policy.DirectoryEntities))
+	}
+}
+
+// spillingClient returns a client whose cache keeps nothing in memory, so every cached response is spilled to dir.
+func spillingClient(srv *sactest.Server, dir string) *sac.Client {
+	return srv.NewClient(sac.WithCacheOptions(sac.CacheOptions{
+		TTL:      time.Hour,
+		MaxBytes: 1,
+		Dir:      dir,
+	}))
+}
+
+func spillDirs(t *testing.T, dir string) []string {
+	t.Helper()
+
+	dirs, err := filepath.Glob(filepath.Join(dir, "baton-broadcom-sac-cache-*"))
+	if err != nil {
+		t.Fatalf("listing spill directories: %v", err)
+	}
+	return dirs
+}
+
+func TestClientCloseRemovesSpilledResponses(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	dir := t.TempDir()
+	client := spillingClient(srv, dir)
+
+	if _, err := client.ListRoles(ctx); err != nil {
+		t.Fatalf("listing roles: %v", err)
+	}
+	if _, err := client.ListRoles(ctx); err != nil {
+		t.Fatalf("listing roles from disk: %v", err)
+	}
+	if stats := client.CacheStats(); stats.OnDisk != 1 || stats.Hits != 1 {
+		t.Fatalf("got %d responses on disk and %d hits, want 1 and 1", stats.OnDisk, stats.Hits)
+	}
+	if dirs := spillDirs(t, dir); len(dirs) != 1 {
+		t.Fatalf("got spill directories %v, want one", dirs)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("closing: %v", err)
+	}
+	if dirs := spillDirs(t, dir); len(dirs) != 0 {
+		t.Errorf("got spill directories %v after closing, want none", dirs)
+	}
+
+	// The client still spills after it was closed.
+	if _, _, err := client.ListPolicies(ctx, 0); err != nil {
+		t.Fatalf("listing policies after closing: %v", err)
+	}
+	if dirs := spillDirs(t, dir); len(dirs) != 1 {
+		t.Errorf("got spill directories %v after closing, want one", dirs)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("closing again: %v", err)
+	}
+}
+
+func TestClientRemovesStaleSpillDirectories(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	dir := t.TempDir()
+
+	stale := filepath.Join(dir, "baton-broadcom-sac-cache-stale")
+	recent := filepath.Join(dir, "baton-broadcom-sac-cache-recent")
+	for _, d := range []string{stale, recent} {
+		if err := os.Mkdir(d, 0700); err != nil {
+			t.Fatalf("creating %s: %v", d, err)
+		}
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("aging %s: %v", stale, err)
+	}
+
+	client := spillingClient(srv, dir)
+	t.Cleanup(func() { _ = client.Close() })
+	if _, err := client.ListRoles(ctx); err != nil {
+		t.Fatalf("listing roles: %v", err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("got %v for the stale spill directory, want it removed", err)
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("got %v for the spill directory still in use, want it kept", err)
+	}
+}
@@ -1,37 +1,128 @@
 package sac
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/conductorone/baton-sdk/pkg/uhttp"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
 )
 
 type Client struct {
-	httpClient *http.Client
-	baseUrl    string
-	token      string
+	httpClient  *http.Client
+	baseUrl     string
+	tokenSource *TokenSource
+	retryPolicy RetryPolicy
+	pageSize    int
+	cache       *responseCache
+	lookups     int64
+
+	rateLimitMtx sync.Mutex
+	rateLimit    *RateLimit
 }
 
-func NewClient(httpClient *http.Client, tenant, token string) *Client {
-	baseUrl := fmt.Sprintf("https://api.%s.luminatesec.com/v2", tenant)
-	return &Client{
-		httpClient: httpClient,
-		baseUrl:    baseUrl,
-		token:      token,
+// cacheStatsInterval is how many cache lookups happen between two debug logs of the cache statistics.
+const cacheStatsInterval = 1000
+
+// ClientOption configures optional Client behavior.
+type ClientOption func(*Client)
+
+// WithPageSize sets the page size used by the List* methods.
+func WithPageSize(pageSize int) ClientOption {
+	return func(c *Client) {
+		if pageSize > 0 {
+			c.pageSize = pageSize
+		}
+	}
+}
+
+// WithCacheOptions configures the per-sync response cache. A zero TTL disables caching.
+func WithCacheOptions(opts CacheOptions) ClientOption {
+	return func(c *Client) {
+		c.cache = newResponseCache(opts)
+	}
+}
+
+// WithRetryPolicy overrides the default retry policy.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// ResetCache drops the responses cached by the client. It is called when a new sync starts.
+func (c *Client) ResetCache(ctx context.Context) {
+	stats := c.cache.reset()
+	if stats.Disabled || stats.Hits+stats.Misses == 0 {
+		return
+	}
+
+	ctxzap.Extract(ctx).Debug("sac: response cache reset", cacheStatsFields(stats)...)
+}
+
+// Close removes the responses the client spilled to disk. The client remains usable.
+func (c *Client) Close() error {
+	return c.cache.close()
+}
+
+// CacheStats returns the response cache statistics of the current sync.
+func (c *Client) CacheStats() CacheStats {
+	return c.cache.stats()
+}
+
+func cacheStatsFields(stats CacheStats) []zap.Field {
+	return []zap.Field{
+		zap.Int64("hits", stats.Hits),
+		zap.Int64("misses", stats.Misses),
+		zap.Float64("hit_rate", stats.HitRate()),
+		zap.Int("entries", stats.Entries),
+		zap.Int64("bytes", stats.Bytes),
+		zap.Int("on_disk", stats.OnDisk),
+		zap.Int64("evicted", stats.Evicted),
 	}
 }
 
-type AuthResponse struct {
-	AccessToken      string `json:"access_token"`
-	ExpiresIn        int    `json:"expires_in"`
-	Scope            string `json:"scope"`
-	Error            string `json:"error"`
-	ErrorDescription string `json:"error_description"`
+// DefaultBaseURL returns the SAC API root for the given tenant.
+func DefaultBaseURL(tenant string) string {
+	return fmt.Sprintf("https://api.%s.luminatesec.com", tenant)
+}
+
+// DefaultPortalURL returns the SAC admin portal root for the given tenant.
+func DefaultPortalURL(tenant string) string {
+	return fmt.Sprintf("https://%s.admin.luminatesec.com", tenant)
+}
+
+// TokenURL returns the OAuth token endpoint served under the given API root.
+func TokenURL(baseURL string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/v1/oauth/token"
+}
+
+// NewClient returns a client for the v2 API served under baseURL, e.g. the value of DefaultBaseURL.
+func NewClient(httpClient *http.Client, baseURL string, tokenSource *TokenSource, opts ...ClientOption) *Client {
+	c := &Client{
+		httpClient:  httpClient,
+		baseUrl:     strings.TrimSuffix(baseURL, "/") + "/v2",
+		tokenSource: tokenSource,
+		retryPolicy: DefaultRetryPolicy(),
+		pageSize:    DefaultPageSize,
+		cache:       newResponseCache(DefaultCacheOptions()),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 type PaginationData struct {
@@ -48,70 +139,58 @@ type PaginationData struct {
 
 const applicationJSONHeader = "application/json"
 
-// returns query params with pagination options with PageOffset.
-func paginationQueryOffset(nextPage string) url.Values {
-	q := url.Values{}
-	if nextPage != "" {
-		q.Set("pageOffset", nextPage)
+// GetTenantSettings returns the settings of the tenant the client is authenticated to.
+func (c *Client) GetTenantSettings(ctx context.Context) (TenantSettings, error) {
+	url := fmt.Sprintf("%s/settings/tenant", c.baseUrl)
+	var res TenantSettings
+
+	if err := c.doRequest(ctx, url, &res, nil); err != nil {
+		return TenantSettings{}, err
 	}
-	q.Set("perPage", "50")
-	return q
+
+	return res, nil
 }
 
-// returns query params with pagination options with PageNumber.
-func paginationQueryPages(pageNumber int) url.Values {
+// ListIdentityProviders returns the identity providers of the tenant, including the local directory.
+func (c *Client) ListIdentityProviders(ctx context.Context) ([]IdentityProvider, error) {
+	providersUrl := fmt.Sprintf("%s/identities/settings/identity-providers", c.baseUrl)
+
 	q := url.Values{}
-	q.Set("page", fmt.Sprintf("%v", pageNumber))
-	q.Set("size", "50")
-	return q
-}
+	q.Set("includeLocal", "true")
 
-// CreateBearerToken creates a bearer token for the given username, password, and tenant.
-func CreateBearerToken(ctx context.Context, username, password, tenant string) (string, error) {
-	httpClient, err := uhttp.NewClient(ctx, uhttp.WithLogger(true, ctxzap.Extract(ctx)))
-	if err != nil {
-		return "", err
+	var res []IdentityProvider
+	if err := c.doRequest(ctx, providersUrl, &res, q); err != nil {
+		return nil, err
 	}
 
-	url := fmt.Sprintf("https://api.%s.luminatesec.com/v1/oauth/token", tenant)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
-	if err != nil {
-		return "", err
-	}
+	return res, nil
+}
 
-	req.Header.Add("Accept", applicationJSONHeader)
-	req.Header.Add("Content-Type", applicationJSONHeader)
-	req.SetBasicAuth(username, password)
+// ErrNoLocalIdentityProvider is returned by LocalIdentityProvider when the tenant has no local identity provider.
+var ErrNoLocalIdentityProvider = errors.New("sac: the tenant has no local identity provider")
 
-	resp, err := httpClient.Do(req)
+// LocalIdentityProvider returns the local identity provider of the tenant, the only one whose users and groups
+// can be changed through the API.
+func (c *Client) LocalIdentityProvider(ctx context.Context) (IdentityProvider, error) {
+	identityProviders, err := c.ListIdentityProviders(ctx)
 	if err != nil {
-		return "", err
-	}
-
-	defer resp.Body.Close()
-
-	var res AuthResponse
-	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
-		return "", err
+		return IdentityProvider{}, err
 	}
 
-	if res.Error != "" {
-		return "", fmt.Errorf("error creating bearer token. %s: %s", res.Error, res.ErrorDescription)
+	for _, identityProvider := range identityProviders {
+		if identityProvider.IsLocal() {
+			return identityProvider, nil
+		}
 	}
 
-	return res.AccessToken, nil
+	return IdentityProvider{}, ErrNoLocalIdentityProvider
 }
 
 // ListIdentityProviderIDs returns a list of identity provider ids.
 func (c *Client) ListIdentityProviderIDs(ctx context.Context) ([]string, error) {
 	var providerIDs []string
-	providersUrl := fmt.Sprintf("%s/identities/settings/identity-providers", c.baseUrl)
-
-	q := url.Values{}
-	q.Set("includeLocal", "true")
-
-	var res []IdentityProvider
-	if err := c.doRequest(ctx, providersUrl, &res, q); err != nil {
+	res, err := c.ListIdentityProviders(ctx)
+	if err != nil {
 		return nil, err
 	}
 
@@ -124,20 +203,86 @@ func (c *Client) ListIdentityProviderIDs(ctx context.Context) ([]string, error)
 
 // ListUserPerProvider returns a list of users for the given identity provider id.
 func (c *Client) ListUsersPerProvider(ctx context.Context, identityProviderId string, nextPage string) ([]User, PaginationData, error) {
+	return c.listUsersPerProvider(ctx, identityProviderId, PageRequest{Style: OffsetPagination, Offset: nextPage, Size: c.pageSize})
+}
+
+func (c *Client) listUsersPerProvider(ctx context.Context, identityProviderId string, page PageRequest) ([]User, PaginationData, error) {
 	url := fmt.Sprintf("%s/identities/%s/users", c.baseUrl, identityProviderId)
 	var res struct {
 		Content []User `json:"content"`
 		PaginationData
 	}
 
-	q := paginationQueryOffset(nextPage)
-	if err := c.doRequest(ctx, url, &res, q); err != nil {
+	if err := c.doRequest(ctx, url, &res, page.query()); err != nil {
 		return nil, PaginationData{}, err
 	}
 
 	return res.Content, res.PaginationData, nil
 }
 
+// GetUser returns the detail record of a user of the given identity provider.
+func (c *Client) GetUser(ctx context.Context, identityProviderId string, userId string) (UserDetails, error) {
+	url := fmt.Sprintf("%s/identities/%s/users/%s", c.baseUrl, identityProviderId, userId)
+	var res UserDetails
+
+	if err := c.doRequest(ctx, url, &res, nil); err != nil {
+		return UserDetails{}, err
+	}
+
+	return res, nil
+}
+
+// CreateLocalUser creates a user in the local identity provider and returns it.
+func (c *Client) CreateLocalUser(ctx context.Context, identityProviderId string, user LocalUser) (User, error) {
+	url := fmt.Sprintf("%s/identities/%s/users", c.baseUrl, identityProviderId)
+	var res User
+
+	if _, err := c.doUncached(ctx, http.MethodPost, url, nil, user, &res); err != nil {
+		return User{}, err
+	}
+
+	c.cache.invalidate(url)
+
+	return res, nil
+}
+
+// SetUserBlocked blocks or unblocks a user of the local identity provider. A blocked user cannot sign in.
+func (c *Client) SetUserBlocked(ctx context.Context, identityProviderId string, userId string, blocked bool) error {
+	action := "unblock"
+	if blocked {
+		action = "block"
+	}
+
+	url := fmt.Sprintf("%s/identities/%s/users/%s/%s", c.baseUrl, identityProviderId, userId, action)
+	if _, err := c.doUncached(ctx, http.MethodPut, url, nil, nil, nil); err != nil {
+		return err
+	}
+
+	c.cache.invalidate(fmt.Sprintf("%s/identities/%s/users", c.baseUrl, identityProviderId))
+
+	return nil
+}
+
+// DeleteUser deletes a user of the local identity provider, which also removes it from its groups.
+func (c *Client) DeleteUser(ctx context.Context, identityProviderId string, userId string) error {
+	url := fmt.Sprintf("%s/identities/%s/users/%s", c.baseUrl, identityProviderId, userId)
+	if _, err := c.doUncached(ctx, http.MethodDelete, url, nil, nil, nil); err != nil {
+		return err
+	}
+
+	c.cache.invalidate(fmt.Sprintf("%s/identities/%s/users", c.baseUrl, identityProviderId))
+	c.cache.invalidate(fmt.Sprintf("%s/identities/%s/groups", c.baseUrl, identityProviderId))
+
+	return nil
+}
+
+// UsersPager returns a Pager over the users of the given identity provider.
+func (c *Client) UsersPager(identityProviderId string, pageSize int) *Pager[User] {
+	return NewPager(OffsetPagination, pageSize, func(ctx context.Context, page PageRequest) ([]User, PaginationData, error) {
+		return c.listUsersPerProvider(ctx, identityProviderId, page)
+	})
+}
+
 // ListAllUsers returns a list of all users for all identity providers.
 func (c *Client) ListAllUsers(ctx context.Context) ([]User, error) {
 	var allUsers []User
@@ -146,19 +291,12 @@ func (c *Client) ListAllUsers(ctx context.Context) ([]User, error) {
 		return nil, fmt.Errorf("error fetching identity providers: %w", err)
 	}
 	for _, identityProvider := range identityProviders {
-		var nextPage string
-		for {
-			users, paginationData, err := c.ListUsersPerProvider(ctx, identityProvider, nextPage)
-			if err != nil {
-				return nil, fmt.Errorf("error fetching users: %w", err)
-			}
-
-			allUsers = append(allUsers, users...)
-			if paginationData.Last {
-				break
-			}
-			nextPage = paginationData.NextPage
+		users, err := c.UsersPager(identityProvider, c.pageSize).All(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching users: %w", err)
 		}
+
+		allUsers = append(allUsers, users...)
 	}
 
 	return allUsers, nil
@@ -166,21 +304,73 @@ func (c *Client) ListAllUsers(ctx context.Context) ([]User, error) {
 
 // ListGroups returns a list of groups for the given identity provider id.
 func (c *Client) ListGroupsPerProvider(ctx context.Context, identityProviderId string, nextPage string) ([]Group, PaginationData, error) {
+	return c.listGroupsPerProvider(ctx, identityProviderId, PageRequest{Style: OffsetPagination, Offset: nextPage, Size: c.pageSize})
+}
+
+func (c *Client) listGroupsPerProvider(ctx context.Context, identityProviderId string, page PageRequest) ([]Group, PaginationData, error) {
 	url := fmt.Sprintf("%s/identities/%s/groups", c.baseUrl, identityProviderId)
 	var res struct {
 		Content []Group `json:"content"`
 		PaginationData
 	}
 
-	q := paginationQueryOffset(nextPage)
-
-	if err := c.doRequest(ctx, url, &res, q); err != nil {
+	if err := c.doRequest(ctx, url, &res, page.query()); err != nil {
 		return nil, PaginationData{}, err
 	}
 
 	return res.Content, res.PaginationData, nil
 }
 
+// groupRequest is the payload creating or renaming a group.
+type groupRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateLocalGroup creates a group in the local identity provider and returns it.
+func (c *Client) CreateLocalGroup(ctx context.Context, identityProviderId string, name string) (Group, error) {
+	url := fmt.Sprintf("%s/identities/%s/groups", c.baseUrl, identityProviderId)
+	var res Group
+
+	if _, err := c.doUncached(ctx, http.MethodPost, url, nil, groupRequest{Name: name}, &res); err != nil {
+		return Group{}, err
+	}
+
+	c.cache.invalidate(url)
+
+	return res, nil
+}
+
+// RenameGroup renames a group of the local identity provider.
+func (c *Client) RenameGroup(ctx context.Context, identityProviderId string, groupId string, name string) error {
+	url := fmt.Sprintf("%s/identities/%s/groups/%s", c.baseUrl, identityProviderId, groupId)
+	if _, err := c.doUncached(ctx, http.MethodPut, url, nil, groupRequest{Name: name}, nil); err != nil {
+		return err
+	}
+
+	c.cache.invalidate(fmt.Sprintf("%s/identities/%s/groups", c.baseUrl, identityProviderId))
+
+	return nil
+}
+
+// DeleteGroup deletes a group of the local identity provider. Its members are not deleted.
+func (c *Client) DeleteGroup(ctx context.Context, identityProviderId string, groupId string) error {
+	url := fmt.Sprintf("%s/identities/%s/groups/%s", c.baseUrl, identityProviderId, groupId)
+	if _, err := c.doUncached(ctx, http.MethodDelete, url, nil, nil, nil); err != nil {
+		return err
+	}
+
+	c.cache.invalidate(fmt.Sprintf("%s/identities/%s/groups", c.baseUrl, identityProviderId))
+
+	return nil
+}
+
+// GroupsPager returns a Pager over the groups of the given identity provider.
+func (c *Client) GroupsPager(identityProviderId string, pageSize int) *Pager[Group] {
+	return NewPager(OffsetPagination, pageSize, func(ctx context.Context, page PageRequest) ([]Group, PaginationData, error) {
+		return c.listGroupsPerProvider(ctx, identityProviderId, page)
+	})
+}
+
 // ListAllGroups returns a list of all groups for all identity providers.
 func (c *Client) ListAllGroups(ctx context.Context) ([]Group, error) {
 	var allGroups []Group
@@ -189,21 +379,12 @@ func (c *Client) ListAllGroups(ctx context.Context) ([]Group, error) {
 		return nil, fmt.Errorf("error fetching identity providers: %w", err)
 	}
 	for _, identityProvider := range identityProviders {
-		var nextPage string
-		for {
-			groups, paginationData, err := c.ListGroupsPerProvider(ctx, identityProvider, nextPage)
-			if err != nil {
-				return nil, fmt.Errorf("error fetching groups: %w", err)
-			}
-
-			allGroups = append(allGroups, groups...)
-
-			if paginationData.Last {
-				break
-			}
-
-			nextPage = paginationData.NextPage
+		groups, err := c.GroupsPager(identityProvider, c.pageSize).All(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching groups: %w", err)
 		}
+
+		allGroups = append(allGroups, groups...)
 	}
 
 	return allGroups, nil
@@ -211,58 +392,327 @@ func (c *Client) ListAllGroups(ctx context.Context) ([]Group, error) {
 
 // ListGroupUsers returns a list of users for the given identity provider id and group id.
 func (c *Client) ListGroupMembers(ctx context.Context, identityProviderId string, groupId string, nextPage string) ([]User, PaginationData, error) {
+	return c.listGroupMembers(ctx, identityProviderId, groupId, PageRequest{Style: OffsetPagination, Offset: nextPage, Size: c.pageSize})
+}
+
+func (c *Client) listGroupMembers(ctx context.Context, identityProviderId string, groupId string, page PageRequest) ([]User, PaginationData, error) {
 	url := fmt.Sprintf("%s/identities/%s/groups/%s/users", c.baseUrl, identityProviderId, groupId)
 	var res struct {
 		Content []User `json:"content"`
 		PaginationData
 	}
 
-	q := paginationQueryOffset(nextPage)
-
-	if err := c.doRequest(ctx, url, &res, q); err != nil {
+	if err := c.doRequest(ctx, url, &res, page.query()); err != nil {
 		return nil, PaginationData{}, err
 	}
 
 	return res.Content, res.PaginationData, nil
 }
 
+// GroupMembersPager returns a Pager over the members of the given group.
+func (c *Client) GroupMembersPager(identityProviderId string, groupId string, pageSize int) *Pager[User] {
+	return NewPager(OffsetPagination, pageSize, func(ctx context.Context, page PageRequest) ([]User, PaginationData, error) {
+		return c.listGroupMembers(ctx, identityProviderId, groupId, page)
+	})
+}
+
+// AddGroupMember adds a user to a group of the given identity provider. Only groups of the local
+// identity provider can be changed through the API.
+func (c *Client) AddGroupMember(ctx context.Context, identityProviderId string, groupId string, userId string) error {
+	url := fmt.Sprintf("%s/identities/%s/groups/%s/users/%s", c.baseUrl, identityProviderId, groupId, userId)
+	if _, err := c.doUncached(ctx, http.MethodPut, url, nil, nil, nil); err != nil {
+		return err
+	}
+
+	c.cache.invalidate(fmt.Sprintf("%s/identities/%s/groups/%s/users", c.baseUrl, identityProviderId, groupId))
+
+	return nil
+}
+
+// RemoveGroupMember removes a user from a group of the given identity provider.
+func (c *Client) RemoveGroupMember(ctx context.Context, identityProviderId string, groupId string, userId string) error {
+	url := fmt.Sprintf("%s/identities/%s/groups/%s/users/%s", c.baseUrl, identityProviderId, groupId, userId)
+	if _, err := c.doUncached(ctx, http.MethodDelete, url, nil, nil, nil); err != nil {
+		return err
+	}
+
+	c.cache.invalidate(fmt.Sprintf("%s/identities/%s/groups/%s/users", c.baseUrl, identityProviderId, groupId))
+
+	return nil
+}
+
 // List Policies returns a list of policies.
 func (c *Client) ListPolicies(ctx context.Context, pageNumber int) ([]Policy, PaginationData, error) {
+	return c.listPolicies(ctx, PageRequest{Style: PageNumberPagination, Number: pageNumber, Size: c.pageSize})
+}
+
+func (c *Client) listPolicies(ctx context.Context, page PageRequest) ([]Policy, PaginationData, error) {
 	url := fmt.Sprintf("%s/policies", c.baseUrl)
 	var res struct {
 		Content []Policy `json:"content"`
 		PaginationData
 	}
 
-	q := paginationQueryPages(pageNumber)
-
-	if err := c.doRequest(ctx, url, &res, q); err != nil {
+	if err := c.doRequest(ctx, url, &res, page.query()); err != nil {
 		return nil, PaginationData{}, err
 	}
 
+	// Seed the cache so GetPolicy does not download the policies again.
+	for _, policy := range res.Content {
+		if body, err := json.Marshal(policy); err == nil {
+			c.cache.set(cacheKey(fmt.Sprintf("%s/policies/%s", c.baseUrl, policy.ID), nil), body)
+		}
+	}
+
 	return res.Content, res.PaginationData, nil
 }
 
+// PoliciesPager returns a Pager over all policies.
+func (c *Client) PoliciesPager(pageSize int) *Pager[Policy] {
+	return NewPager(PageNumberPagination, pageSize, c.listPolicies)
+}
+
 // ListAllPolicies returns a paginated list of all policies.
 func (c *Client) ListAllPolicies(ctx context.Context) ([]Policy, error) {
-	var nextPage int
-	var allPolicies []Policy
-	for {
-		policies, paginationData, err := c.ListPolicies(ctx, nextPage)
-		if err != nil {
-			return nil, fmt.Errorf("error fetching policies: %w", err)
-		}
+	policies, err := c.PoliciesPager(c.pageSize).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching policies: %w", err)
+	}
+
+	return policies, nil
+}
 
-		allPolicies = append(allPolicies, policies...)
+// ListApplications returns a page of applications.
+func (c *Client) ListApplications(ctx context.Context, pageNumber int) ([]Application, PaginationData, error) {
+	return c.listApplications(ctx, PageRequest{Style: PageNumberPagination, Number: pageNumber, Size: c.pageSize})
+}
 
-		if paginationData.Last {
-			break
-		}
+func (c *Client) listApplications(ctx context.Context, page PageRequest) ([]Application, PaginationData, error) {
+	url := fmt.Sprintf("%s/applications", c.baseUrl)
+	var res struct {
+		Content []Application `json:"content"`
+		PaginationData
+	}
+
+	if err := c.doRequest(ctx, url, &res, page.query()); err != nil {
+		return nil, PaginationData{}, err
+	}
+
+	return res.Content, res.PaginationData, nil
+}
+
+// ApplicationsPager returns a Pager over all applications.
+func (c *Client) ApplicationsPager(pageSize int) *Pager[Application] {
+	return NewPager(PageNumberPagination, pageSize, c.listApplications)
+}
+
+// ListAllApplications returns a paginated list of all applications.
+func (c *Client) ListAllApplications(ctx context.Context) ([]Application, error) {
+	applications, err := c.ApplicationsPager(c.pageSize).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching applications: %w", err)
+	}
+
+	return applications, nil
+}
+
+// GetApplication returns an application by ID.
+func (c *Client) GetApplication(ctx context.Context, applicationId string) (Application, error) {
+	url := fmt.Sprintf("%s/applications/%s", c.baseUrl, applicationId)
+	var res Application
+
+	if err := c.doRequest(ctx, url, &res, nil); err != nil {
+		return Application{}, err
+	}
+
+	return res, nil
+}
+
+// ListSites returns a page of sites.
+func (c *Client) ListSites(ctx context.Context, pageNumber int) ([]Site, PaginationData, error) {
+	return c.listSites(ctx, PageRequest{Style: PageNumberPagination, Number: pageNumber, Size: c.pageSize})
+}
+
+func (c *Client) listSites(ctx context.Context, page PageRequest) ([]Site, PaginationData, error) {
+	url := fmt.Sprintf("%s/sites", c.baseUrl)
+	var res struct {
+		Content []Site `json:"content"`
+		PaginationData
+	}
+
+	if err := c.doRequest(ctx, url, &res, page.query()); err != nil {
+		return nil, PaginationData{}, err
+	}
+
+	return res.Content, res.PaginationData, nil
+}
+
+// SitesPager returns a Pager over all sites.
+func (c *Client) SitesPager(pageSize int) *Pager[Site] {
+	return NewPager(PageNumberPagination, pageSize, c.listSites)
+}
+
+// ListSiteConnectors returns a page of the connectors deployed in a site.
+func (c *Client) ListSiteConnectors(ctx context.Context, siteId string, pageNumber int) ([]Connector, PaginationData, error) {
+	return c.listSiteConnectors(ctx, siteId, PageRequest{Style: PageNumberPagination, Number: pageNumber, Size: c.pageSize})
+}
+
+func (c *Client) listSiteConnectors(ctx context.Context, siteId string, page PageRequest) ([]Connector, PaginationData, error) {
+	url := fmt.Sprintf("%s/sites/%s/connectors", c.baseUrl, siteId)
+	var res struct {
+		Content []Connector `json:"content"`
+		PaginationData
+	}
+
+	if err := c.doRequest(ctx, url, &res, page.query()); err != nil {
+		return nil, PaginationData{}, err
+	}
+
+	return res.Content, res.PaginationData, nil
+}
+
+// SiteConnectorsPager returns a Pager over all connectors of a site.
+func (c *Client) SiteConnectorsPager(siteId string, pageSize int) *Pager[Connector] {
+	return NewPager(PageNumberPagination, pageSize, func(ctx context.Context, page PageRequest) ([]Connector, PaginationData, error) {
+		return c.listSiteConnectors(ctx, siteId, page)
+	})
+}
+
+// ListRoles returns the administrative roles of the tenant.
+func (c *Client) ListRoles(ctx context.Context) ([]Role, error) {
+	url := fmt.Sprintf("%s/roles", c.baseUrl)
+	var res []Role
+
+	if err := c.doRequest(ctx, url, &res, nil); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ListRoleBindings returns a page of role bindings.
+func (c *Client) ListRoleBindings(ctx context.Context, pageNumber int) ([]RoleBinding, PaginationData, error) {
+	return c.listRoleBindings(ctx, PageRequest{Style: PageNumberPagination, Number: pageNumber, Size: c.pageSize})
+}
+
+func (c *Client) listRoleBindings(ctx context.Context, page PageRequest) ([]RoleBinding, PaginationData, error) {
+	url := fmt.Sprintf("%s/roles/bindings", c.baseUrl)
+	var res struct {
+		Content []RoleBinding `json:"content"`
+		PaginationData
+	}
+
+	if err := c.doRequest(ctx, url, &res, page.query()); err != nil {
+		return nil, PaginationData{}, err
+	}
+
+	return res.Content, res.PaginationData, nil
+}
+
+// RoleBindingsPager returns a Pager over all role bindings.
+func (c *Client) RoleBindingsPager(pageSize int) *Pager[RoleBinding] {
+	return NewPager(PageNumberPagination, pageSize, c.listRoleBindings)
+}
+
+// ListAllRoleBindings returns a paginated list of all role bindings.
+func (c *Client) ListAllRoleBindings(ctx context.Context) ([]RoleBinding, error) {
+	bindings, err := c.RoleBindingsPager(c.pageSize).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching role bindings: %w", err)
+	}
+
+	return bindings, nil
+}
+
+// CreateRoleBinding binds a role to a user or group and returns the created binding.
+func (c *Client) CreateRoleBinding(ctx context.Context, binding RoleBinding) (RoleBinding, error) {
+	url := fmt.Sprintf("%s/roles/bindings", c.baseUrl)
+	var res RoleBinding
+
+	if _, err := c.doUncached(ctx, http.MethodPost, url, nil, binding, &res); err != nil {
+		return RoleBinding{}, err
+	}
+
+	c.cache.invalidate(url)
+
+	return res, nil
+}
+
+// DeleteRoleBinding removes a role binding.
+func (c *Client) DeleteRoleBinding(ctx context.Context, bindingId string) error {
+	url := fmt.Sprintf("%s/roles/bindings/%s", c.baseUrl, bindingId)
+	if _, err := c.doUncached(ctx, http.MethodDelete, url, nil, nil, nil); err != nil {
+		return err
+	}
+
+	c.cache.invalidate(fmt.Sprintf("%s/roles/bindings", c.baseUrl))
+
+	return nil
+}
+
+// ListCollections returns a page of collections.
+func (c *Client) ListCollections(ctx context.Context, pageNumber int) ([]Collection, PaginationData, error) {
+	return c.listCollections(ctx, PageRequest{Style: PageNumberPagination, Number: pageNumber, Size: c.pageSize})
+}
+
+func (c *Client) listCollections(ctx context.Context, page PageRequest) ([]Collection, PaginationData, error) {
+	url := fmt.Sprintf("%s/collections", c.baseUrl)
+	var res struct {
+		Content []Collection `json:"content"`
+		PaginationData
+	}
+
+	if err := c.doRequest(ctx, url, &res, page.query()); err != nil {
+		return nil, PaginationData{}, err
+	}
+
+	return res.Content, res.PaginationData, nil
+}
+
+// CollectionsPager returns a Pager over all collections.
+func (c *Client) CollectionsPager(pageSize int) *Pager[Collection] {
+	return NewPager(PageNumberPagination, pageSize, c.listCollections)
+}
+
+func (c *Client) listCollectionObjects(ctx context.Context, collectionId string, page PageRequest) ([]CollectionObject, PaginationData, error) {
+	url := fmt.Sprintf("%s/collections/%s/objects", c.baseUrl, collectionId)
+	var res struct {
+		Content []CollectionObject `json:"content"`
+		PaginationData
+	}
+
+	if err := c.doRequest(ctx, url, &res, page.query()); err != nil {
+		return nil, PaginationData{}, err
+	}
+
+	return res.Content, res.PaginationData, nil
+}
 
-		nextPage = paginationData.Number + 1
+// CollectionObjectsPager returns a Pager over the applications, sites and policies of a collection.
+func (c *Client) CollectionObjectsPager(collectionId string, pageSize int) *Pager[CollectionObject] {
+	return NewPager(PageNumberPagination, pageSize, func(ctx context.Context, page PageRequest) ([]CollectionObject, PaginationData, error) {
+		return c.listCollectionObjects(ctx, collectionId, page)
+	})
+}
+
+// ListAllCollections returns a paginated list of all collections.
+func (c *Client) ListAllCollections(ctx context.Context) ([]Collection, error) {
+	collections, err := c.CollectionsPager(c.pageSize).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching collections: %w", err)
 	}
 
-	return allPolicies, nil
+	return collections, nil
+}
+
+// ListAllCollectionObjects returns a paginated list of all objects of a collection.
+func (c *Client) ListAllCollectionObjects(ctx context.Context, collectionId string) ([]CollectionObject, error) {
+	objects, err := c.CollectionObjectsPager(collectionId, c.pageSize).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching collection objects: %w", err)
+	}
+
+	return objects, nil
 }
 
 // GetPolicy returns a policy by ID.
@@ -277,32 +727,283 @@ func (c *Client) GetPolicy(ctx context.Context, policyId string) (Policy, error)
 	return res, nil
 }
 
-func (c *Client) doRequest(ctx context.Context, url string, res interface{}, query url.Values) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// ErrMissingETag is returned by ModifyPolicyDirectoryEntities when the API serves a policy without an ETag, so a
+// concurrent change could not be detected.
+var ErrMissingETag = errors.New("sac: the policy was served without an ETag")
+
+// policyUpdateAttempts is how many times ModifyPolicyDirectoryEntities reloads a policy that changed while it was
+// being updated.
+const policyUpdateAttempts = 3
+
+// ModifyPolicyDirectoryEntities loads a policy, passes its directory entities to modify and, if modify reports a
+// change, writes the returned entities back. The policy is written with the ETag it was loaded with, so a policy
+// changed concurrently, e.g. in the admin portal, is reloaded and modified again instead of being overwritten.
+// Every other field of the policy is written back as it was loaded. A policy served without an ETag is not written
+// and ErrMissingETag is returned.
+func (c *Client) ModifyPolicyDirectoryEntities(ctx context.Context, policyId string, modify func(entities []DirectoryEntity) ([]DirectoryEntity, bool)) error {
+	url := fmt.Sprintf("%s/policies/%s", c.baseUrl, policyId)
+
+	var err error
+	for attempt := 1; attempt <= policyUpdateAttempts; attempt++ {
+		err = c.modifyPolicyDirectoryEntities(ctx, url, modify)
+		if !IsPreconditionFailed(err) {
+			break
+		}
+
+		ctxzap.Extract(ctx).Debug("sac: policy changed while being updated, reloading", zap.String("policy_id", policyId), zap.Int("attempt", attempt))
+	}
+
 	if err != nil {
 		return err
 	}
 
-	if query != nil {
-		req.URL.RawQuery = query.Encode()
+	c.cache.invalidate(fmt.Sprintf("%s/policies", c.baseUrl))
+
+	return nil
+}
+
+func (c *Client) modifyPolicyDirectoryEntities(ctx context.Context, url string, modify func(entities []DirectoryEntity) ([]DirectoryEntity, bool)) error {
+	// The policy is kept as raw JSON so fields the client does not model survive the round trip.
+	var policy map[string]json.RawMessage
+	header, err := c.doUncached(ctx, http.MethodGet, url, nil, nil, &policy)
+	if err != nil {
+		return err
+	}
+
+	var entities []DirectoryEntity
+	if raw, ok := policy["directoryEntities"]; ok {
+		if err := json.Unmarshal(raw, &entities); err != nil {
+			return fmt.Errorf("invalid policy directory entities: %w", err)
+		}
+	}
+
+	entities, changed := modify(entities)
+	if !changed {
+		return nil
 	}
 
-	req.Header.Add("Accept", applicationJSONHeader)
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	resp, err := c.httpClient.Do(req)
+	if entities == nil {
+		entities = []DirectoryEntity{}
+	}
+	policy["directoryEntities"], err = json.Marshal(entities)
 	if err != nil {
 		return err
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	etag := header.Get("ETag")
+	if etag == "" {
+		return ErrMissingETag
+	}
+
+	ifMatch := http.Header{}
+	ifMatch.Set("If-Match", etag)
+
+	_, err = c.doUncached(ctx, http.MethodPut, url, ifMatch, policy, nil)
+	return err
+}
+
+func (c *Client) doRequest(ctx context.Context, url string, res interface{}, query url.Values) error {
+	key := cacheKey(url, query)
+	cached := !isUncached(ctx)
+	if cached {
+		body, ok := c.cache.get(key)
+		c.logCacheStats(ctx)
+		if ok {
+			return json.Unmarshal(body, &res)
+		}
+	}
+
+	resp, err := c.send(ctx, http.MethodGet, url, query, nil, nil)
+	if err != nil {
+		return err
 	}
 
 	defer resp.Body.Close()
 
-	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, &res); err != nil {
 		return err
 	}
 
+	if cached {
+		c.cache.set(key, body)
+	}
+
 	return nil
 }
+
+// doUncached sends a request bypassing the response cache, with body, if any, encoded as JSON and the extra
+// header, if any. It decodes the response into res, if any, and returns the response header.
+func (c *Client) doUncached(ctx context.Context, method string, url string, header http.Header, body interface{}, res interface{}) (http.Header, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.send(ctx, method, url, nil, header, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newAPIError(resp)
+	}
+
+	if res == nil {
+		return resp.Header, nil
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(respBody) == 0 {
+		return resp.Header, nil
+	}
+
+	if err := json.Unmarshal(respBody, &res); err != nil {
+		return nil, err
+	}
+
+	return resp.Header, nil
+}
+
+func cacheKey(url string, query url.Values) string {
+	if len(query) == 0 {
+		return url
+	}
+	return url + "?" + query.Encode()
+}
+
+// logCacheStats periodically logs the cache statistics at debug level.
+func (c *Client) logCacheStats(ctx context.Context) {
+	if !c.cache.enabled() || atomic.AddInt64(&c.lookups, 1)%cacheStatsInterval != 0 {
+		return
+	}
+
+	ctxzap.Extract(ctx).Debug("sac: response cache statistics", cacheStatsFields(c.cache.stats())...)
+}
+
+// RateLimit returns the rate limit state reported by the latest response that carried rate limit headers, or nil
+// when none did or the reported window has ended.
+func (c *Client) RateLimit() *RateLimit {
+	c.rateLimitMtx.Lock()
+	defer c.rateLimitMtx.Unlock()
+
+	if c.rateLimit == nil || (!c.rateLimit.ResetAt.IsZero() && time.Now().After(c.rateLimit.ResetAt)) {
+		return nil
+	}
+
+	rl := *c.rateLimit
+	return &rl
+}
+
+func (c *Client) observeRateLimit(header http.Header) {
+	rl := parseRateLimit(header, time.Now())
+	if rl == nil {
+		return
+	}
+
+	c.rateLimitMtx.Lock()
+	c.rateLimit = rl
+	c.rateLimitMtx.Unlock()
+}
+
+// send performs an authenticated request, with the extra header, if any, and body as its JSON payload when it
+// is not nil. If the API rejects the bearer token, the token is refreshed and the request is retried once.
+// Throttled requests, and transient server errors of idempotent requests, are retried according to the client's
+// retry policy; the last response is returned once retries are exhausted.
+func (c *Client) send(ctx context.Context, method string, url string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	l := ctxzap.Extract(ctx)
+	start := time.Now()
+	reauthenticated := false
+
+	for attempt := 1; ; attempt++ {
+		token, err := c.tokenSource.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get access token: %w", err)
+		}
+
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+		if err != nil {
+			return nil, err
+		}
+
+		if query != nil {
+			req.URL.RawQuery = query.Encode()
+		}
+
+		req.Header.Add("Accept", applicationJSONHeader)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+		if body != nil {
+			req.Header.Add("Content-Type", applicationJSONHeader)
+		}
+		for k, vs := range header {
+			for _, v := range vs {
+				req.Header.Add(k, v)
+			}
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			// A request that is not idempotent may have been processed before the connection failed.
+			delay := c.retryPolicy.backoff(attempt)
+			if ctx.Err() != nil || method == http.MethodPost || !c.retryPolicy.allowRetry(attempt, time.Since(start)+delay) {
+				return nil, err
+			}
+
+			l.Debug("sac: request failed, retrying", zap.String("method", method), zap.String("url", url), zap.Int("attempt", attempt), zap.Duration("delay", delay), zap.Error(err))
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		c.observeRateLimit(resp.Header)
+
+		if resp.StatusCode == http.StatusUnauthorized && !reauthenticated {
+			resp.Body.Close()
+			c.tokenSource.Invalidate(token)
+			reauthenticated = true
+			attempt--
+			continue
+		}
+
+		if !isRetryableRequest(method, resp.StatusCode) {
+			return resp, nil
+		}
+
+		delay := c.retryPolicy.delay(attempt, resp.Header, time.Now())
+		if !c.retryPolicy.allowRetry(attempt, time.Since(start)+delay) {
+			return resp, nil
+		}
+
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBodySize))
+		resp.Body.Close()
+
+		l.Debug("sac: retrying request", zap.String("method", method), zap.String("url", url), zap.Int("status", resp.StatusCode), zap.Int("attempt", attempt), zap.Duration("delay", delay))
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package sac_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+)
+
+func TestClientReloadsConcurrentlyModifiedPolicies(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	srv.Inject(sactest.Fault{Method: http.MethodPut, Path: policiesPath, Status: http.StatusPreconditionFailed, Times: 1})
+	client := srv.NewClient()
+
+	loads := 0
+	err := client.ModifyPolicyDirectoryEntities(ctx, "policy-ssh", func(entities []sac.DirectoryEntity) ([]sac.DirectoryEntity, bool) {
+		loads++
+		return nil, true
+	})
+	if err != nil {
+		t.Fatalf("modifying policy: %v", err)
+	}
+
+	if loads != 2 {
+		t.Errorf("policy was modified %d times, want 2", loads)
+	}
+	if got := srv.Requests(http.MethodPut, policiesPath+"/policy-ssh"); got != 2 {
+		t.Errorf("got %d policy updates, want 2", got)
+	}
+}
@@ -0,0 +1,148 @@
+package sac
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBodySize limits how much of an error response body is read into memory.
+const maxErrorBodySize = 64 * 1024
+
+// ErrorResponse is the error payload returned by the SAC API.
+type ErrorResponse struct {
+	Status           int    `json:"status"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	Message          string `json:"message"`
+	Path             string `json:"path"`
+}
+
+// APIError is returned when the SAC API responds with a non-2xx status code.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Endpoint   string
+	RequestID  string
+	Response   *ErrorResponse
+	// RateLimit is set when the response carried rate limit headers.
+	RateLimit *RateLimit
+	// Body holds the raw response body when it could not be decoded as an ErrorResponse.
+	Body string
+}
+
+func (e *APIError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "sac: %s %s returned %d %s", e.Method, e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+
+	if msg := e.message(); msg != "" {
+		sb.WriteString(": ")
+		sb.WriteString(msg)
+	}
+
+	if e.RequestID != "" {
+		fmt.Fprintf(&sb, " (request id %s)", e.RequestID)
+	}
+
+	return sb.String()
+}
+
+func (e *APIError) message() string {
+	if e.Response == nil {
+		return e.Body
+	}
+
+	switch {
+	case e.Response.Message != "":
+		return e.Response.Message
+	case e.Response.ErrorDescription != "":
+		return e.Response.ErrorDescription
+	default:
+		return e.Response.Error
+	}
+}
+
+// newAPIError builds an APIError from a failed response. It consumes the response body.
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  requestID(resp.Header),
+		RateLimit:  parseRateLimit(resp.Header, time.Now()),
+	}
+
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.Endpoint = resp.Request.URL.Path
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil || len(body) == 0 {
+		return apiErr
+	}
+
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp != (ErrorResponse{}) {
+		apiErr.Response = &errResp
+		return apiErr
+	}
+
+	apiErr.Body = strings.TrimSpace(string(body))
+	return apiErr
+}
+
+func requestID(header http.Header) string {
+	for _, key := range []string{"X-Request-Id", "X-Correlation-Id", "X-Amzn-Requestid"} {
+		if v := header.Get(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func hasStatus(err error, statusCodes ...int) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	for _, code := range statusCodes {
+		if apiErr.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound reports whether err is an APIError for a missing object.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err is an APIError caused by invalid credentials.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is an APIError caused by missing permissions.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsConflict reports whether err is an APIError caused by a conflicting change.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsPreconditionFailed reports whether err is an APIError caused by an object that changed since it was read.
+func IsPreconditionFailed(err error) bool {
+	return hasStatus(err, http.StatusPreconditionFailed)
+}
+
+// IsRateLimited reports whether err is an APIError caused by throttling.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
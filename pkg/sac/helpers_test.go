@@ -0,0 +1,26 @@
+package sac_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+)
+
+// testRetryPolicy retries quickly, so tests exercising retries do not wait for the default backoff.
+var testRetryPolicy = sac.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     10 * time.Millisecond,
+}
+
+const policiesPath = "/v2/policies"
+
+func newTestServer(t *testing.T) *sactest.Server {
+	t.Helper()
+
+	srv := sactest.NewServer(sactest.DefaultFixtures())
+	t.Cleanup(srv.Close)
+	return srv
+}
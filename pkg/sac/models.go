@@ -1,5 +1,7 @@
 package sac
 
+import "strings"
+
 type User struct {
 	Username           string `json:"username"`
 	FirstName          string `json:"first_name"`
@@ -12,6 +14,29 @@ type User struct {
 	NotificationEmail  string `json:"notification_email"`
 	ID                 string `json:"id"`
 	IdentityProviderID string `json:"identity_provider_id"`
+	// IdentifierInProvider is the ID of the user in its identity provider, used to assign policies.
+	IdentifierInProvider string `json:"identifier_in_provider"`
+}
+
+// LocalUser holds the fields of a user created in the local identity provider.
+type LocalUser struct {
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	// NotificationEmail is where SAC sends the user's notifications, including the invitation to set a password.
+	// Empty uses Email.
+	NotificationEmail string `json:"notification_email,omitempty"`
+}
+
+// UserDetails is the detail record of a user, which holds more than the user list does.
+type UserDetails struct {
+	User
+	CreatedOn            string `json:"created_on"`
+	LastLogin            string `json:"last_login"`
+	MFAEnrolled          bool   `json:"mfa_enrolled"`
+	IdentityProviderName string `json:"identity_provider_name"`
+	PhoneNumber          string `json:"phone_number"`
 }
 
 type Group struct {
@@ -19,6 +44,8 @@ type Group struct {
 	Name               string `json:"name"`
 	RepositoryType     string `json:"repository_type"`
 	IdentityProviderID string `json:"identity_provider_id"`
+	// IdentifierInProvider is the ID of the group in its identity provider, used to assign policies.
+	IdentifierInProvider string `json:"identifier_in_provider"`
 }
 
 type IdentityProvider struct {
@@ -30,6 +57,12 @@ type IdentityProvider struct {
 	AuthenticatorID interface{} `json:"authenticator_id"`
 }
 
+// IsLocal reports whether the identity provider is SAC's local directory, the only one whose users and groups
+// can be changed through the API.
+func (i IdentityProvider) IsLocal() bool {
+	return strings.EqualFold(i.Provider, "local")
+}
+
 type Account struct {
 	Name string
 	ID   string
@@ -42,9 +75,37 @@ type Policy struct {
 	Enabled           bool              `json:"enabled"`
 	CreatedAt         string            `json:"createdAt"`
 	DirectoryEntities []DirectoryEntity `json:"directoryEntities"`
+	Applications      []string          `json:"applications"`
+	Conditions        PolicyConditions  `json:"conditions"`
+	SSHSettings       *SSHSettings      `json:"sshSettings,omitempty"`
+	RDPSettings       *RDPSettings      `json:"rdpSettings,omitempty"`
 	PolicyAccess      string            `json:"PolicyAccess"`
 }
 
+type PolicyConditions struct {
+	SourceIP      []string                `json:"sourceIp"`
+	Location      []string                `json:"location"`
+	ManagedDevice *ManagedDeviceCondition `json:"managedDevice,omitempty"`
+}
+
+type ManagedDeviceCondition struct {
+	OpswatMetaAccess           bool `json:"opswatMetaAccess"`
+	SymantecCloudSoc           bool `json:"symantecCloudSoc"`
+	SymantecWebSecurityService bool `json:"symantecWebSecurityService"`
+}
+
+// SSHSettings lists the accounts users assigned to a policy may log in to SSH applications as.
+type SSHSettings struct {
+	Accounts           []string `json:"accounts"`
+	AutoMapping        bool     `json:"autoMapping"`
+	FullUPNAutoMapping bool     `json:"fullUpnAutoMapping"`
+	AgentForward       bool     `json:"agentForward"`
+}
+
+type RDPSettings struct {
+	LongTermPassword bool `json:"longTermPassword"`
+}
+
 // Either User or Group.
 type DirectoryEntity struct {
 	ID                   string `json:"id"`
@@ -54,3 +115,88 @@ type DirectoryEntity struct {
 	Type                 string `json:"type"`
 	DisplayName          string `json:"displayName"`
 }
+
+type Application struct {
+	ID                 string                        `json:"id"`
+	Name               string                        `json:"name"`
+	Description        string                        `json:"description"`
+	Type               string                        `json:"type"`
+	Enabled            bool                          `json:"enabled"`
+	SiteID             string                        `json:"siteId"`
+	ConnectionSettings ApplicationConnectionSettings `json:"connectionSettings"`
+}
+
+type ApplicationConnectionSettings struct {
+	InternalAddress string `json:"internalAddress"`
+	ExternalAddress string `json:"externalAddress"`
+	LuminateAddress string `json:"luminateAddress"`
+}
+
+type Site struct {
+	ID                     string   `json:"id"`
+	Name                   string   `json:"name"`
+	Description            string   `json:"description"`
+	Region                 string   `json:"region"`
+	MuteHealthNotification bool     `json:"muteHealthNotification"`
+	Connectors             []string `json:"connectors"`
+}
+
+type Connector struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	SiteID         string `json:"siteId"`
+	Enabled        bool   `json:"enabled"`
+	Status         string `json:"status"`
+	Version        string `json:"version"`
+	DeploymentType string `json:"deploymentType"`
+	LastSeen       string `json:"lastSeen"`
+}
+
+// Role is an administrative role. Tenant roles apply to the whole tenant, collection roles to the objects of the
+// collections they are bound in.
+type Role struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Permissions []string `json:"permissions"`
+}
+
+// TenantAdminRoleName is the name of the built-in role with full administrative access to the tenant.
+const TenantAdminRoleName = "Tenant Admin"
+
+// IsTenantAdmin reports whether the role is the built-in tenant admin role.
+func (r Role) IsTenantAdmin() bool {
+	return r.Type == "tenant" && strings.EqualFold(r.Name, TenantAdminRoleName)
+}
+
+// RoleBinding binds a role to a user or group, on the whole tenant or, when CollectionID is set, on a collection.
+type RoleBinding struct {
+	ID           string          `json:"id"`
+	RoleID       string          `json:"roleId"`
+	CollectionID string          `json:"collectionId"`
+	Entity       DirectoryEntity `json:"entity"`
+}
+
+// Collection groups applications, sites and policies so their administration can be delegated with collection
+// role bindings.
+type Collection struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ParentID    string `json:"parentId"`
+}
+
+type CollectionObject struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type TenantSettings struct {
+	Name        string          `json:"name"`
+	DisplayName string          `json:"displayName"`
+	Region      string          `json:"region"`
+	LicenseType string          `json:"licenseType"`
+	Features    map[string]bool `json:"features"`
+}
@@ -0,0 +1,176 @@
+package sac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// DefaultPageSize is the page size used when none is configured.
+const DefaultPageSize = 50
+
+// ErrPaginationLoop is returned by a Pager when the API hands out a page it already returned.
+var ErrPaginationLoop = errors.New("sac: pagination did not advance")
+
+// PaginationStyle selects how a SAC list endpoint is paged.
+type PaginationStyle int
+
+const (
+	// OffsetPagination pages with the opaque pageOffset token returned in nextPage.
+	OffsetPagination PaginationStyle = iota
+	// PageNumberPagination pages with a zero-based page number.
+	PageNumberPagination
+)
+
+// PageRequest identifies the page a PageFetcher should load.
+type PageRequest struct {
+	Style PaginationStyle
+	// Offset is the pageOffset token, used with OffsetPagination. Empty requests the first page.
+	Offset string
+	// Number is the zero-based page number, used with PageNumberPagination.
+	Number int
+	Size   int
+}
+
+func (r PageRequest) query() url.Values {
+	size := r.Size
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+
+	if r.Style == PageNumberPagination {
+		return paginationQueryPages(r.Number, size)
+	}
+	return paginationQueryOffset(r.Offset, size)
+}
+
+// returns query params with pagination options with PageOffset.
+func paginationQueryOffset(nextPage string, perPage int) url.Values {
+	q := url.Values{}
+	if nextPage != "" {
+		q.Set("pageOffset", nextPage)
+	}
+	q.Set("perPage", strconv.Itoa(perPage))
+	return q
+}
+
+// returns query params with pagination options with PageNumber.
+func paginationQueryPages(pageNumber int, size int) url.Values {
+	q := url.Values{}
+	q.Set("page", fmt.Sprintf("%v", pageNumber))
+	q.Set("size", strconv.Itoa(size))
+	return q
+}
+
+// PageFetcher loads a single page of a list endpoint.
+type PageFetcher[T any] func(ctx context.Context, req PageRequest) ([]T, PaginationData, error)
+
+// Pager walks a paginated SAC list endpoint one page at a time.
+type Pager[T any] struct {
+	fetch PageFetcher[T]
+	next  PageRequest
+	done  bool
+	seen  map[string]struct{}
+}
+
+// NewPager returns a Pager starting at the first page. A pageSize of zero or less uses DefaultPageSize.
+func NewPager[T any](style PaginationStyle, pageSize int, fetch PageFetcher[T]) *Pager[T] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	return &Pager[T]{
+		fetch: fetch,
+		next: PageRequest{
+			Style: style,
+			Size:  pageSize,
+		},
+		seen: make(map[string]struct{}),
+	}
+}
+
+// Done reports whether the last page has been fetched.
+func (p *Pager[T]) Done() bool {
+	return p.done
+}
+
+// Next fetches the next page. It returns no items and no error once Done reports true.
+// If the API hands out a page that was already fetched, Next returns the items it received
+// together with ErrPaginationLoop and stops.
+func (p *Pager[T]) Next(ctx context.Context) ([]T, error) {
+	if p.done {
+		return nil, nil
+	}
+
+	req := p.next
+	p.seen[pageKey(req)] = struct{}{}
+
+	items, paginationData, err := p.fetch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if paginationData.Last || len(items) == 0 {
+		p.done = true
+		return items, nil
+	}
+
+	switch req.Style {
+	case PageNumberPagination:
+		p.next.Number = paginationData.Number + 1
+	case OffsetPagination:
+		if paginationData.NextPage == "" {
+			p.done = true
+			return items, nil
+		}
+		p.next.Offset = paginationData.NextPage
+	}
+
+	if _, ok := p.seen[pageKey(p.next)]; ok {
+		p.done = true
+		return items, fmt.Errorf("%w: page %s requested twice", ErrPaginationLoop, pageKey(p.next))
+	}
+
+	return items, nil
+}
+
+// ForEach calls fn for every item on every remaining page, stopping at the first error.
+func (p *Pager[T]) ForEach(ctx context.Context, fn func(item T) error) error {
+	for !p.done {
+		items, err := p.Next(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// All collects the items of every remaining page.
+func (p *Pager[T]) All(ctx context.Context) ([]T, error) {
+	var rv []T
+	err := p.ForEach(ctx, func(item T) error {
+		rv = append(rv, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+func pageKey(req PageRequest) string {
+	if req.Style == PageNumberPagination {
+		return strconv.Itoa(req.Number)
+	}
+	return strconv.Quote(req.Offset)
+}
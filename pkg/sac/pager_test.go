@@ -0,0 +1,83 @@
+package sac_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+)
+
+func TestPagerDetectsLoops(t *testing.T) {
+	ctx := context.Background()
+	fetches := 0
+	pager := sac.NewPager(sac.OffsetPagination, 1, func(ctx context.Context, req sac.PageRequest) ([]string, sac.PaginationData, error) {
+		fetches++
+		// The API keeps handing out the first page.
+		return []string{"item"}, sac.PaginationData{NextPage: "page-1"}, nil
+	})
+
+	items, err := pager.All(ctx)
+	if !errors.Is(err, sac.ErrPaginationLoop) {
+		t.Fatalf("got error %v, want %v", err, sac.ErrPaginationLoop)
+	}
+	if items != nil {
+		t.Errorf("got items %v, want none", items)
+	}
+	if fetches != 2 {
+		t.Errorf("got %d fetches, want 2", fetches)
+	}
+	if !pager.Done() {
+		t.Error("pager is not done after detecting a loop")
+	}
+}
+
+func TestPagerWalksPageNumbers(t *testing.T) {
+	ctx := context.Background()
+	pager := sac.NewPager(sac.PageNumberPagination, 1, func(ctx context.Context, req sac.PageRequest) ([]int, sac.PaginationData, error) {
+		return []int{req.Number}, sac.PaginationData{Number: req.Number, Last: req.Number == 2}, nil
+	})
+
+	items, err := pager.All(ctx)
+	if err != nil {
+		t.Fatalf("walking pages: %v", err)
+	}
+	if len(items) != 3 || items[0] != 0 || items[2] != 2 {
+		t.Errorf("got items %v, want [0 1 2]", items)
+	}
+}
+
+func TestPagerStopsOnEmptyPage(t *testing.T) {
+	ctx := context.Background()
+	fetches := 0
+	pager := sac.NewPager(sac.OffsetPagination, 2, func(ctx context.Context, req sac.PageRequest) ([]string, sac.PaginationData, error) {
+		fetches++
+		if req.Offset == "" {
+			return []string{"a", "b"}, sac.PaginationData{NextPage: "page-2"}, nil
+		}
+		return nil, sac.PaginationData{NextPage: "page-3"}, nil
+	})
+
+	items, err := pager.All(ctx)
+	if err != nil {
+		t.Fatalf("walking pages: %v", err)
+	}
+	if len(items) != 2 || fetches != 2 {
+		t.Errorf("got %d items in %d fetches, want 2 items in 2 fetches", len(items), fetches)
+	}
+}
+
+func TestPagerWalksTheServerByOffset(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := srv.NewClient()
+
+	users, err := client.UsersPager(sactest.LocalIdentityProviderID, 1).All(ctx)
+	if err != nil {
+		t.Fatalf("listing users: %v", err)
+	}
+	if len(users) != 2 || users[0].ID != "user-alice" || users[1].ID != "user-bob" {
+		t.Errorf("got users %+v, want alice and bob", users)
+	}
+}
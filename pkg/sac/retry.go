@@ -0,0 +1,174 @@
+package sac
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how requests failing with throttling or transient server errors are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per request, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. It doubles on every subsequent retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps a single computed backoff delay.
+	MaxBackoff time.Duration
+	// MaxElapsed bounds the total time spent on a request including all retries.
+	MaxElapsed time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		MaxElapsed:     2 * time.Minute,
+	}
+}
+
+// RateLimit describes the rate limit state reported by the SAC API in response headers.
+type RateLimit struct {
+	Limit     int64
+	Remaining int64
+	ResetAt   time.Time
+}
+
+// isRetryableRequest reports whether a request that failed with statusCode may be sent again. Requests that are
+// not idempotent are only retried when they were throttled, as SAC rejects those before processing them.
+func isRetryableRequest(method string, statusCode int) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return isRetryableStatus(statusCode)
+	default:
+		return statusCode == http.StatusTooManyRequests
+	}
+}
+
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before retry number attempt (starting at 1), using exponential backoff with jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1)) //nolint:gosec // jitter does not need a cryptographic source
+}
+
+// delay returns how long to wait before retrying a request that failed after the given attempt.
+// Server hints in Retry-After and rate limit headers take precedence over the computed backoff.
+func (p RetryPolicy) delay(attempt int, header http.Header, now time.Time) time.Duration {
+	if d, ok := retryAfter(header, now); ok {
+		return d
+	}
+
+	if rl := parseRateLimit(header, now); rl != nil && rl.Remaining == 0 && rl.ResetAt.After(now) {
+		return rl.ResetAt.Sub(now)
+	}
+
+	return p.backoff(attempt)
+}
+
+// allowRetry reports whether another attempt may be made after attempt attempts have been made
+// and the next one would start after elapsed.
+func (p RetryPolicy) allowRetry(attempt int, elapsed time.Duration) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+
+	return p.MaxElapsed <= 0 || elapsed <= p.MaxElapsed
+}
+
+// retryAfter parses the Retry-After header, which is either a number of seconds or an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(v); err == nil {
+		if at.Before(now) {
+			return 0, true
+		}
+		return at.Sub(now), true
+	}
+
+	return 0, false
+}
+
+// parseRateLimit reads the X-RateLimit-* and Retry-After headers. The reset header is accepted both as a unix
+// timestamp and as a number of seconds from now.
+func parseRateLimit(header http.Header, now time.Time) *RateLimit {
+	limit, limitErr := strconv.ParseInt(header.Get("X-RateLimit-Limit"), 10, 64)
+	remaining, remainingErr := strconv.ParseInt(header.Get("X-RateLimit-Remaining"), 10, 64)
+	retryDelay, hasRetryAfter := retryAfter(header, now)
+	if limitErr != nil && remainingErr != nil && !hasRetryAfter {
+		return nil
+	}
+
+	rl := &RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+	}
+
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		// Values this large can only be unix timestamps, anything smaller is a relative delay.
+		if reset > 1_000_000_000 {
+			rl.ResetAt = time.Unix(reset, 0)
+		} else {
+			rl.ResetAt = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+
+	if hasRetryAfter && rl.ResetAt.IsZero() {
+		rl.ResetAt = now.Add(retryDelay)
+	}
+
+	return rl
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
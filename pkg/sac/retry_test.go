@@ -0,0 +1,91 @@
+package sac_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+)
+
+func TestClientRetriesThrottledRequests(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	srv.Throttle(policiesPath, 1, time.Second)
+	client := srv.NewClient(sac.WithRetryPolicy(testRetryPolicy))
+
+	start := time.Now()
+	policies, err := client.ListAllPolicies(ctx)
+	if err != nil {
+		t.Fatalf("listing policies: %v", err)
+	}
+	if len(policies) != 2 {
+		t.Errorf("got %d policies, want 2", len(policies))
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want the 1s Retry-After delay", elapsed)
+	}
+	if got := srv.Requests(http.MethodGet, policiesPath); got != 2 {
+		t.Errorf("got %d policy requests, want 2", got)
+	}
+}
+
+func TestClientGivesUpOnThrottling(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	srv.Throttle(policiesPath, 0, 0)
+	client := srv.NewClient(sac.WithRetryPolicy(testRetryPolicy))
+
+	_, err := client.ListAllPolicies(ctx)
+	if !sac.IsRateLimited(err) {
+		t.Fatalf("got error %v, want rate limited", err)
+	}
+
+	if got := srv.Requests(http.MethodGet, policiesPath); got != testRetryPolicy.MaxAttempts {
+		t.Errorf("got %d policy requests, want %d", got, testRetryPolicy.MaxAttempts)
+	}
+}
+
+func TestClientRetriesTransientServerErrors(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	srv.Inject(sactest.Fault{Path: policiesPath, Status: http.StatusServiceUnavailable, Times: 2})
+	client := srv.NewClient(sac.WithRetryPolicy(testRetryPolicy))
+
+	if _, err := client.ListAllPolicies(ctx); err != nil {
+		t.Fatalf("listing policies: %v", err)
+	}
+	if got := srv.Requests(http.MethodGet, policiesPath); got != 3 {
+		t.Errorf("got %d policy requests, want 3", got)
+	}
+}
+
+func TestClientReportsRateLimit(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "100")
+	header.Set("X-RateLimit-Remaining", "42")
+	header.Set("X-RateLimit-Reset", "60")
+	srv.Inject(sactest.Fault{Path: "/v2/roles", Status: http.StatusOK, Times: 1, Header: header, Body: "[]"})
+	client := srv.NewClient()
+
+	if rl := client.RateLimit(); rl != nil {
+		t.Fatalf("got rate limit %+v before any request, want none", rl)
+	}
+
+	if _, err := client.ListRoles(ctx); err != nil {
+		t.Fatalf("listing roles: %v", err)
+	}
+
+	rl := client.RateLimit()
+	if rl == nil {
+		t.Fatal("got no rate limit, want the one reported by the response")
+	}
+	if rl.Limit != 100 || rl.Remaining != 42 || rl.ResetAt.IsZero() {
+		t.Errorf("got rate limit %+v, want limit 100, remaining 42 and a reset time", rl)
+	}
+}
@@ -0,0 +1,253 @@
+package sactest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+)
+
+// Fixtures is the data served by the fake SAC API.
+type Fixtures struct {
+	// TenantSettings is served by the tenant settings endpoint, which returns 404 when it is nil.
+	TenantSettings    *sac.TenantSettings    `json:"tenant_settings"`
+	IdentityProviders []sac.IdentityProvider `json:"identity_providers"`
+	// Users holds the users of each identity provider, keyed by identity provider ID.
+	Users map[string][]sac.User `json:"users"`
+	// UserDetails holds the detail record fields of users, keyed by user ID. The user fields of the detail
+	// record are always taken from Users.
+	UserDetails map[string]sac.UserDetails `json:"user_details"`
+	// Groups holds the groups of each identity provider, keyed by identity provider ID.
+	Groups map[string][]sac.Group `json:"groups"`
+	// GroupMembers holds the IDs of the members of each group, keyed by group ID.
+	GroupMembers map[string][]string `json:"group_members"`
+	Policies     []sac.Policy        `json:"policies"`
+	Applications []sac.Application   `json:"applications"`
+	Sites        []sac.Site          `json:"sites"`
+	// Connectors holds the connectors deployed in each site, keyed by site ID.
+	Connectors   map[string][]sac.Connector `json:"connectors"`
+	Roles        []sac.Role                 `json:"roles"`
+	RoleBindings []sac.RoleBinding          `json:"role_bindings"`
+	Collections  []sac.Collection           `json:"collections"`
+	// CollectionObjects holds the objects of each collection, keyed by collection ID.
+	CollectionObjects map[string][]sac.CollectionObject `json:"collection_objects"`
+}
+
+// LoadFixtures reads fixtures from a JSON file.
+func LoadFixtures(path string) (Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixtures{}, err
+	}
+
+	var f Fixtures
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Fixtures{}, fmt.Errorf("sactest: invalid fixtures in %s: %w", path, err)
+	}
+
+	return f, nil
+}
+
+// Local and Okta identity provider IDs used by DefaultFixtures.
+const (
+	LocalIdentityProviderID = "local-idp"
+	OktaIdentityProviderID  = "okta-idp"
+)
+
+// DefaultFixtures returns a small tenant with a local and an Okta identity provider, a few users and groups,
+// a site with its connector and applications, policies granting access to them to both users and groups,
+// tenant and collection roles, and a collection holding the site.
+func DefaultFixtures() Fixtures {
+	alice := sac.User{
+		ID:                 "user-alice",
+		Username:           "alice",
+		FirstName:          "Alice",
+		LastName:           "Anderson",
+		Email:              "alice@example.com",
+		RepositoryType:     "local",
+		IsAdmin:            true,
+		IdentityProviderID: LocalIdentityProviderID,
+	}
+	bob := sac.User{
+		ID:                 "user-bob",
+		Username:           "bob",
+		FirstName:          "Bob",
+		LastName:           "Brown",
+		Email:              "bob@example.com",
+		RepositoryType:     "local",
+		Blocked:            true,
+		IdentityProviderID: LocalIdentityProviderID,
+	}
+	carol := sac.User{
+		ID:                 "user-carol",
+		Username:           "carol@example.com",
+		FirstName:          "Carol",
+		LastName:           "Clark",
+		Email:              "carol@example.com",
+		RepositoryType:     "okta",
+		IdentityProviderID: OktaIdentityProviderID,
+	}
+
+	aliceEntity := sac.DirectoryEntity{
+		ID:                   alice.ID,
+		IdentifierInProvider: alice.ID,
+		IdentityProviderID:   LocalIdentityProviderID,
+		IdentityProviderType: "local",
+		Type:                 "User",
+		DisplayName:          "Alice Anderson",
+	}
+	carolEntity := sac.DirectoryEntity{
+		ID:                   carol.ID,
+		IdentifierInProvider: carol.ID,
+		IdentityProviderID:   OktaIdentityProviderID,
+		IdentityProviderType: "okta",
+		Type:                 "User",
+		DisplayName:          "Carol Clark",
+	}
+	engineeringEntity := sac.DirectoryEntity{
+		ID:                   "group-engineering",
+		IdentifierInProvider: "group-engineering",
+		IdentityProviderID:   OktaIdentityProviderID,
+		IdentityProviderType: "okta",
+		Type:                 "Group",
+		DisplayName:          "Engineering",
+	}
+
+	return Fixtures{
+		TenantSettings: &sac.TenantSettings{
+			Name:        "acme",
+			DisplayName: "Acme Corp",
+			Region:      "us",
+			LicenseType: "enterprise",
+			Features:    map[string]bool{"ssh": true, "rdp": true, "dlp": false},
+		},
+		IdentityProviders: []sac.IdentityProvider{
+			{ID: LocalIdentityProviderID, Name: "Local", Provider: "Local", IsAuthenticator: true, IsUserStore: true},
+			{ID: OktaIdentityProviderID, Name: "Okta", Provider: "Okta", IsAuthenticator: true, IsUserStore: true},
+		},
+		Users: map[string][]sac.User{
+			LocalIdentityProviderID: {alice, bob},
+			OktaIdentityProviderID:  {carol},
+		},
+		UserDetails: map[string]sac.UserDetails{
+			alice.ID: {
+				CreatedOn:            "2023-01-10T09:00:00Z",
+				LastLogin:            "2024-05-01T08:30:00Z",
+				MFAEnrolled:          true,
+				IdentityProviderName: "Local",
+				PhoneNumber:          "+1 555 0100",
+			},
+		},
+		Groups: map[string][]sac.Group{
+			LocalIdentityProviderID: {
+				{ID: "group-admins", Name: "Admins", RepositoryType: "local", IdentityProviderID: LocalIdentityProviderID},
+			},
+			OktaIdentityProviderID: {
+				{ID: "group-engineering", Name: "Engineering", RepositoryType: "okta", IdentityProviderID: OktaIdentityProviderID},
+			},
+		},
+		GroupMembers: map[string][]string{
+			"group-admins":      {alice.ID},
+			"group-engineering": {carol.ID},
+		},
+		Policies: []sac.Policy{
+			{
+				ID:      "policy-ssh",
+				Name:    "SSH access",
+				Type:    "ACCESS",
+				Enabled: true,
+				DirectoryEntities: []sac.DirectoryEntity{
+					aliceEntity,
+				},
+				Applications: []string{"app-ssh"},
+				SSHSettings: &sac.SSHSettings{
+					Accounts: []string{"ubuntu"},
+				},
+			},
+			{
+				ID:      "policy-web",
+				Name:    "Web access",
+				Type:    "ACCESS",
+				Enabled: true,
+				DirectoryEntities: []sac.DirectoryEntity{
+					engineeringEntity,
+				},
+				Applications: []string{"app-web"},
+			},
+		},
+		Applications: []sac.Application{
+			{
+				ID:      "app-ssh",
+				Name:    "Bastion",
+				Type:    "SSH",
+				Enabled: true,
+				SiteID:  "site-dc1",
+				ConnectionSettings: sac.ApplicationConnectionSettings{
+					InternalAddress: "tcp://10.0.0.10:22",
+					ExternalAddress: "bastion.example.luminatesec.com",
+				},
+			},
+			{
+				ID:      "app-web",
+				Name:    "Wiki",
+				Type:    "HTTP",
+				Enabled: true,
+				SiteID:  "site-dc1",
+				ConnectionSettings: sac.ApplicationConnectionSettings{
+					InternalAddress: "http://10.0.0.20:8080",
+					ExternalAddress: "https://wiki.example.luminatesec.com",
+				},
+			},
+		},
+		Sites: []sac.Site{
+			{ID: "site-dc1", Name: "DC1", Region: "us-east", Connectors: []string{"connector-dc1-a"}},
+		},
+		Connectors: map[string][]sac.Connector{
+			"site-dc1": {
+				{
+					ID:             "connector-dc1-a",
+					Name:           "dc1-a",
+					SiteID:         "site-dc1",
+					Enabled:        true,
+					Status:         "connected",
+					Version:        "3.7.0",
+					DeploymentType: "linux",
+					LastSeen:       "2024-01-02T03:04:05Z",
+				},
+			},
+		},
+		Roles: []sac.Role{
+			{ID: "role-tenant-admin", Name: "Tenant Admin", Type: "tenant"},
+			{ID: "role-tenant-viewer", Name: "Tenant Viewer", Type: "tenant"},
+			{ID: "role-collection-admin", Name: "Collection Admin", Type: "collection"},
+		},
+		RoleBindings: []sac.RoleBinding{
+			{
+				ID:     "binding-alice-admin",
+				RoleID: "role-tenant-admin",
+				Entity: aliceEntity,
+			},
+			{
+				ID:     "binding-engineering-viewer",
+				RoleID: "role-tenant-viewer",
+				Entity: engineeringEntity,
+			},
+			{
+				ID:           "binding-carol-production",
+				RoleID:       "role-collection-admin",
+				CollectionID: "collection-production",
+				Entity:       carolEntity,
+			},
+		},
+		Collections: []sac.Collection{
+			{ID: "collection-production", Name: "Production"},
+		},
+		CollectionObjects: map[string][]sac.CollectionObject{
+			"collection-production": {
+				{ID: "site-dc1", Name: "DC1", Type: "Site"},
+				{ID: "policy-ssh", Name: "SSH access", Type: "Policy"},
+			},
+		},
+	}
+}
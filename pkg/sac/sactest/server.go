@@ -0,0 +1,869 @@
+// Package sactest provides an in-memory fake of the Broadcom SAC (Luminate) API for tests.
+//
+// The server emulates the v1 OAuth token endpoint and the v2 identity, group, policy, application, site, role
+// and collection endpoints, including both pagination styles used by the API, the local user, group and group
+// membership write endpoints, policy updates with ETags and role binding changes, and supports injecting errors,
+// latency, expired tokens and throttling.
+package sactest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+)
+
+const (
+	// ClientID and ClientSecret are the credentials accepted by default.
+	ClientID     = "sactest-client-id"
+	ClientSecret = "sactest-client-secret"
+
+	defaultTokenTTL = time.Hour
+)
+
+// Fault makes the server fail matching requests.
+type Fault struct {
+	// Method restricts the fault to one HTTP method. Empty matches every method.
+	Method string
+	// Path is a URL path prefix, e.g. "/v2/policies". Empty matches every path.
+	Path string
+	// Status is the HTTP status code returned.
+	Status int
+	// Times is how many matching requests fail. Zero fails every matching request.
+	Times int
+	// Header is added to the failing responses.
+	Header http.Header
+	// Body is returned as the response body. When empty a SAC style JSON error is returned.
+	Body string
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithCredentials overrides the client credentials accepted by the token endpoint.
+func WithCredentials(clientID, clientSecret string) Option {
+	return func(s *Server) {
+		s.clientID = clientID
+		s.clientSecret = clientSecret
+	}
+}
+
+// WithTokenTTL sets the lifetime of the issued tokens, reported in expires_in.
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.tokenTTL = ttl
+	}
+}
+
+// WithLatency delays every response.
+func WithLatency(latency time.Duration) Option {
+	return func(s *Server) {
+		s.latency = latency
+	}
+}
+
+// Server is a fake SAC API backed by Fixtures.
+type Server struct {
+	*httptest.Server
+
+	clientID     string
+	clientSecret string
+	tokenTTL     time.Duration
+	latency      time.Duration
+
+	mtx      sync.Mutex
+	fixtures Fixtures
+	tokens   map[string]time.Time
+	faults   []*Fault
+	requests map[string]int
+	// versions counts the updates of each policy and is reported as its ETag.
+	versions map[string]int
+}
+
+// NewServer starts a fake SAC API serving fixtures. Callers must Close it.
+func NewServer(fixtures Fixtures, opts ...Option) *Server {
+	s := &Server{
+		clientID:     ClientID,
+		clientSecret: ClientSecret,
+		tokenTTL:     defaultTokenTTL,
+		fixtures:     fixtures,
+		tokens:       make(map[string]time.Time),
+		requests:     make(map[string]int),
+		versions:     make(map[string]int),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// NewClient returns a sac.Client authenticated against the server with the configured credentials.
+func (s *Server) NewClient(opts ...sac.ClientOption) *sac.Client {
+	httpClient := s.Client()
+	tokenSource := sac.NewTokenSource(httpClient, sac.TokenURL(s.URL), s.clientID, s.clientSecret)
+	return sac.NewClient(httpClient, s.URL, tokenSource, opts...)
+}
+
+// Inject registers a fault. Faults are matched in the order they were injected.
+func (s *Server) Inject(f Fault) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	fault := f
+	s.faults = append(s.faults, &fault)
+}
+
+// Throttle makes the next times requests under path fail with 429 and a Retry-After header.
+func (s *Server) Throttle(path string, times int, retryAfter time.Duration) {
+	header := http.Header{}
+	header.Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	header.Set("X-RateLimit-Limit", "100")
+	header.Set("X-RateLimit-Remaining", "0")
+
+	s.Inject(Fault{
+		Path:   path,
+		Status: http.StatusTooManyRequests,
+		Times:  times,
+		Header: header,
+	})
+}
+
+// ExpireTokens revokes every issued token, so the next API request is rejected with 401.
+func (s *Server) ExpireTokens() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.tokens = make(map[string]time.Time)
+}
+
+// Requests returns how many requests were received for the given method and path.
+func (s *Server) Requests(method, path string) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.requests[method+" "+path]
+}
+
+// Update runs fn with exclusive access to the fixtures, so tests can change the data while the server runs.
+func (s *Server) Update(fn func(f *Fixtures)) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	fn(&s.fixtures)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.latency > 0 {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(s.latency):
+		}
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.requests[r.Method+" "+r.URL.Path]++
+
+	if f := s.matchFault(r); f != nil {
+		for k, vs := range f.Header {
+			for _, v := range vs {
+				w.Header().Add(k, v)
+			}
+		}
+		if f.Body != "" {
+			w.WriteHeader(f.Status)
+			_, _ = w.Write([]byte(f.Body))
+			return
+		}
+		writeError(w, r, f.Status, "injected fault")
+		return
+	}
+
+	if r.URL.Path == "/v1/oauth/token" {
+		s.handleToken(w, r)
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, "/v2/") {
+		writeError(w, r, http.StatusNotFound, "no such endpoint")
+		return
+	}
+
+	if !s.authorized(r) {
+		writeError(w, r, http.StatusUnauthorized, "invalid or expired token")
+		return
+	}
+
+	s.route(w, r, strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v2/"), "/"), "/"))
+}
+
+func (s *Server) matchFault(r *http.Request) *Fault {
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != r.Method {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, f.Path) {
+			continue
+		}
+
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+
+	return nil
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != s.clientID || secret != s.clientSecret {
+		writeJSON(w, http.StatusUnauthorized, sac.AuthResponse{
+			Error:            "invalid_client",
+			ErrorDescription: "Bad client credentials",
+		})
+		return
+	}
+
+	token := newToken()
+	s.tokens[token] = time.Now().Add(s.tokenTTL)
+
+	writeJSON(w, http.StatusOK, sac.AuthResponse{
+		AccessToken: token,
+		ExpiresIn:   int(s.tokenTTL.Seconds()),
+		Scope:       "all",
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	expiresAt, ok := s.tokens[token]
+	return ok && time.Now().Before(expiresAt)
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, parts []string) {
+	if r.Method != http.MethodGet {
+		s.routeWrite(w, r, parts)
+		return
+	}
+
+	switch {
+	case match(parts, "settings", "tenant"):
+		s.getTenantSettings(w, r)
+	case match(parts, "identities", "settings", "identity-providers"):
+		s.listIdentityProviders(w, r)
+	case match(parts, "identities", "*", "users"):
+		writeOffsetPage(w, r, s.fixtures.Users[parts[1]])
+	case match(parts, "identities", "*", "users", "*"):
+		s.getUser(w, r, parts[1], parts[3])
+	case match(parts, "identities", "*", "groups"):
+		writeOffsetPage(w, r, s.fixtures.Groups[parts[1]])
+	case match(parts, "identities", "*", "groups", "*", "users"):
+		s.listGroupMembers(w, r, parts[1], parts[3])
+	case match(parts, "policies"):
+		writeNumberedPage(w, r, s.fixtures.Policies)
+	case match(parts, "policies", "*"):
+		s.getPolicy(w, r, parts[1])
+	case match(parts, "applications"):
+		writeNumberedPage(w, r, s.fixtures.Applications)
+	case match(parts, "applications", "*"):
+		s.getApplication(w, r, parts[1])
+	case match(parts, "roles"):
+		writeJSON(w, http.StatusOK, s.fixtures.Roles)
+	case match(parts, "roles", "bindings"):
+		writeNumberedPage(w, r, s.fixtures.RoleBindings)
+	case match(parts, "collections"):
+		writeNumberedPage(w, r, s.fixtures.Collections)
+	case match(parts, "collections", "*", "objects"):
+		writeNumberedPage(w, r, s.fixtures.CollectionObjects[parts[1]])
+	case match(parts, "sites"):
+		writeNumberedPage(w, r, s.fixtures.Sites)
+	case match(parts, "sites", "*", "connectors"):
+		writeNumberedPage(w, r, s.fixtures.Connectors[parts[1]])
+	default:
+		writeError(w, r, http.StatusNotFound, "no such endpoint")
+	}
+}
+
+func (s *Server) routeWrite(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case match(parts, "identities", "*", "users") && r.Method == http.MethodPost:
+		s.createUser(w, r, parts[1])
+	case match(parts, "identities", "*", "users", "*", "block") && r.Method == http.MethodPut:
+		s.setUserBlocked(w, r, parts[1], parts[3], true)
+	case match(parts, "identities", "*", "users", "*", "unblock") && r.Method == http.MethodPut:
+		s.setUserBlocked(w, r, parts[1], parts[3], false)
+	case match(parts, "identities", "*", "users", "*") && r.Method == http.MethodDelete:
+		s.deleteUser(w, r, parts[1], parts[3])
+	case match(parts, "identities", "*", "groups") && r.Method == http.MethodPost:
+		s.createGroup(w, r, parts[1])
+	case match(parts, "identities", "*", "groups", "*") && r.Method == http.MethodPut:
+		s.renameGroup(w, r, parts[1], parts[3])
+	case match(parts, "identities", "*", "groups", "*") && r.Method == http.MethodDelete:
+		s.deleteGroup(w, r, parts[1], parts[3])
+	case match(parts, "identities", "*", "groups", "*", "users", "*") && r.Method == http.MethodPut:
+		s.addGroupMember(w, r, parts[1], parts[3], parts[5])
+	case match(parts, "identities", "*", "groups", "*", "users", "*") && r.Method == http.MethodDelete:
+		s.removeGroupMember(w, r, parts[1], parts[3], parts[5])
+	case match(parts, "policies", "*") && r.Method == http.MethodPut:
+		s.updatePolicy(w, r, parts[1])
+	case match(parts, "roles", "bindings") && r.Method == http.MethodPost:
+		s.createRoleBinding(w, r)
+	case match(parts, "roles", "bindings", "*") && r.Method == http.MethodDelete:
+		s.deleteRoleBinding(w, r, parts[2])
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) getTenantSettings(w http.ResponseWriter, r *http.Request) {
+	if s.fixtures.TenantSettings == nil {
+		writeError(w, r, http.StatusNotFound, "tenant settings not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.fixtures.TenantSettings)
+}
+
+func (s *Server) listIdentityProviders(w http.ResponseWriter, r *http.Request) {
+	includeLocal := r.URL.Query().Get("includeLocal") == "true"
+
+	rv := []sac.IdentityProvider{}
+	for _, idp := range s.fixtures.IdentityProviders {
+		if !includeLocal && idp.IsLocal() {
+			continue
+		}
+		rv = append(rv, idp)
+	}
+
+	writeJSON(w, http.StatusOK, rv)
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request, identityProviderID, userID string) {
+	for _, u := range s.fixtures.Users[identityProviderID] {
+		if u.ID == userID {
+			details := s.fixtures.UserDetails[userID]
+			details.User = u
+			writeJSON(w, http.StatusOK, details)
+			return
+		}
+	}
+
+	writeError(w, r, http.StatusNotFound, "user not found")
+}
+
+// createUser creates a user. Like SAC, only the local identity provider accepts new users.
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request, identityProviderID string) {
+	if !s.isLocal(identityProviderID) {
+		writeError(w, r, http.StatusBadRequest, "users of external identity providers are read-only")
+		return
+	}
+
+	var user sac.LocalUser
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil || user.Username == "" || user.Email == "" {
+		writeError(w, r, http.StatusBadRequest, "username and email are required")
+		return
+	}
+
+	for _, u := range s.fixtures.Users[identityProviderID] {
+		if strings.EqualFold(u.Username, user.Username) {
+			writeError(w, r, http.StatusConflict, "username already exists")
+			return
+		}
+	}
+
+	created := sac.User{
+		ID:                 "user-" + newToken()[:8],
+		Username:           user.Username,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		Email:              user.Email,
+		NotificationEmail:  user.NotificationEmail,
+		RepositoryType:     "local",
+		IdentityProviderID: identityProviderID,
+	}
+
+	if s.fixtures.Users == nil {
+		s.fixtures.Users = make(map[string][]sac.User)
+	}
+	s.fixtures.Users[identityProviderID] = append(s.fixtures.Users[identityProviderID], created)
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) setUserBlocked(w http.ResponseWriter, r *http.Request, identityProviderID, userID string, blocked bool) {
+	if !s.isLocal(identityProviderID) {
+		writeError(w, r, http.StatusBadRequest, "users of external identity providers are read-only")
+		return
+	}
+
+	users := s.fixtures.Users[identityProviderID]
+	for i := range users {
+		if users[i].ID == userID {
+			users[i].Blocked = blocked
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	writeError(w, r, http.StatusNotFound, "user not found")
+}
+
+// deleteUser deletes a user and removes it from the groups it was a member of.
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request, identityProviderID, userID string) {
+	if !s.isLocal(identityProviderID) {
+		writeError(w, r, http.StatusBadRequest, "users of external identity providers are read-only")
+		return
+	}
+
+	users := s.fixtures.Users[identityProviderID]
+	for i, u := range users {
+		if u.ID != userID {
+			continue
+		}
+
+		s.fixtures.Users[identityProviderID] = append(users[:i:i], users[i+1:]...)
+		for groupID, members := range s.fixtures.GroupMembers {
+			for j, memberID := range members {
+				if memberID == userID {
+					s.fixtures.GroupMembers[groupID] = append(members[:j:j], members[j+1:]...)
+					break
+				}
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeError(w, r, http.StatusNotFound, "user not found")
+}
+
+// createGroup creates a group. Like SAC, only the local identity provider accepts new groups and group names
+// are unique.
+func (s *Server) createGroup(w http.ResponseWriter, r *http.Request, identityProviderID string) {
+	if !s.isLocal(identityProviderID) {
+		writeError(w, r, http.StatusBadRequest, "groups of external identity providers are read-only")
+		return
+	}
+
+	name, ok := s.groupName(w, r, identityProviderID, "")
+	if !ok {
+		return
+	}
+
+	created := sac.Group{
+		ID:                 "group-" + newToken()[:8],
+		Name:               name,
+		RepositoryType:     "local",
+		IdentityProviderID: identityProviderID,
+	}
+
+	if s.fixtures.Groups == nil {
+		s.fixtures.Groups = make(map[string][]sac.Group)
+	}
+	s.fixtures.Groups[identityProviderID] = append(s.fixtures.Groups[identityProviderID], created)
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) renameGroup(w http.ResponseWriter, r *http.Request, identityProviderID, groupID string) {
+	if !s.isLocal(identityProviderID) {
+		writeError(w, r, http.StatusBadRequest, "groups of external identity providers are read-only")
+		return
+	}
+
+	name, ok := s.groupName(w, r, identityProviderID, groupID)
+	if !ok {
+		return
+	}
+
+	groups := s.fixtures.Groups[identityProviderID]
+	for i := range groups {
+		if groups[i].ID == groupID {
+			groups[i].Name = name
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	writeError(w, r, http.StatusNotFound, "group not found")
+}
+
+func (s *Server) deleteGroup(w http.ResponseWriter, r *http.Request, identityProviderID, groupID string) {
+	if !s.isLocal(identityProviderID) {
+		writeError(w, r, http.StatusBadRequest, "groups of external identity providers are read-only")
+		return
+	}
+
+	groups := s.fixtures.Groups[identityProviderID]
+	for i, g := range groups {
+		if g.ID == groupID {
+			s.fixtures.Groups[identityProviderID] = append(groups[:i:i], groups[i+1:]...)
+			delete(s.fixtures.GroupMembers, groupID)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	writeError(w, r, http.StatusNotFound, "group not found")
+}
+
+// groupName decodes the name of a group being created or renamed and checks no other group of the identity
+// provider has it. It writes the error response and returns false when the name is invalid.
+func (s *Server) groupName(w http.ResponseWriter, r *http.Request, identityProviderID, groupID string) (string, bool) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		writeError(w, r, http.StatusBadRequest, "name is required")
+		return "", false
+	}
+
+	for _, g := range s.fixtures.Groups[identityProviderID] {
+		if g.ID != groupID && strings.EqualFold(g.Name, req.Name) {
+			writeError(w, r, http.StatusConflict, "group name already exists")
+			return "", false
+		}
+	}
+
+	return req.Name, true
+}
+
+func (s *Server) listGroupMembers(w http.ResponseWriter, r *http.Request, identityProviderID, groupID string) {
+	if !s.hasGroup(identityProviderID, groupID) {
+		writeError(w, r, http.StatusNotFound, "group not found")
+		return
+	}
+
+	members := []sac.User{}
+	for _, userID := range s.fixtures.GroupMembers[groupID] {
+		for _, u := range s.fixtures.Users[identityProviderID] {
+			if u.ID == userID {
+				members = append(members, u)
+			}
+		}
+	}
+
+	writeOffsetPage(w, r, members)
+}
+
+// addGroupMember adds a user to a group. Like SAC, only groups of the local identity provider can be changed.
+func (s *Server) addGroupMember(w http.ResponseWriter, r *http.Request, identityProviderID, groupID, userID string) {
+	if !s.hasGroup(identityProviderID, groupID) || !s.hasUser(identityProviderID, userID) {
+		writeError(w, r, http.StatusNotFound, "group or user not found")
+		return
+	}
+
+	if !s.isLocal(identityProviderID) {
+		writeError(w, r, http.StatusBadRequest, "groups of external identity providers are read-only")
+		return
+	}
+
+	for _, memberID := range s.fixtures.GroupMembers[groupID] {
+		if memberID == userID {
+			writeError(w, r, http.StatusConflict, "user is already a member of the group")
+			return
+		}
+	}
+
+	if s.fixtures.GroupMembers == nil {
+		s.fixtures.GroupMembers = make(map[string][]string)
+	}
+	s.fixtures.GroupMembers[groupID] = append(s.fixtures.GroupMembers[groupID], userID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) removeGroupMember(w http.ResponseWriter, r *http.Request, identityProviderID, groupID, userID string) {
+	if !s.hasGroup(identityProviderID, groupID) {
+		writeError(w, r, http.StatusNotFound, "group not found")
+		return
+	}
+
+	if !s.isLocal(identityProviderID) {
+		writeError(w, r, http.StatusBadRequest, "groups of external identity providers are read-only")
+		return
+	}
+
+	members := s.fixtures.GroupMembers[groupID]
+	for i, memberID := range members {
+		if memberID == userID {
+			s.fixtures.GroupMembers[groupID] = append(members[:i:i], members[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	writeError(w, r, http.StatusNotFound, "user is not a member of the group")
+}
+
+func (s *Server) hasUser(identityProviderID, userID string) bool {
+	for _, u := range s.fixtures.Users[identityProviderID] {
+		if u.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) isLocal(identityProviderID string) bool {
+	for _, idp := range s.fixtures.IdentityProviders {
+		if idp.ID == identityProviderID {
+			return idp.IsLocal()
+		}
+	}
+	return false
+}
+
+func (s *Server) hasGroup(identityProviderID, groupID string) bool {
+	for _, g := range s.fixtures.Groups[identityProviderID] {
+		if g.ID == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) getPolicy(w http.ResponseWriter, r *http.Request, policyID string) {
+	for _, p := range s.fixtures.Policies {
+		if p.ID == policyID {
+			w.Header().Set("ETag", s.policyETag(policyID))
+			writeJSON(w, http.StatusOK, p)
+			return
+		}
+	}
+
+	writeError(w, r, http.StatusNotFound, "policy not found")
+}
+
+// updatePolicy replaces a policy. A request with an If-Match header that does not match the current ETag of the
+// policy fails with 412, like SAC does for a policy changed since it was read.
+func (s *Server) updatePolicy(w http.ResponseWriter, r *http.Request, policyID string) {
+	for i, p := range s.fixtures.Policies {
+		if p.ID != policyID {
+			continue
+		}
+
+		if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != s.policyETag(policyID) {
+			writeError(w, r, http.StatusPreconditionFailed, "policy was modified")
+			return
+		}
+
+		var policy sac.Policy
+		if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid policy")
+			return
+		}
+		policy.ID = policyID
+
+		s.fixtures.Policies[i] = policy
+		s.versions[policyID]++
+
+		w.Header().Set("ETag", s.policyETag(policyID))
+		writeJSON(w, http.StatusOK, policy)
+		return
+	}
+
+	writeError(w, r, http.StatusNotFound, "policy not found")
+}
+
+func (s *Server) createRoleBinding(w http.ResponseWriter, r *http.Request) {
+	var binding sac.RoleBinding
+	if err := json.NewDecoder(r.Body).Decode(&binding); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid role binding")
+		return
+	}
+
+	found := false
+	for _, role := range s.fixtures.Roles {
+		if role.ID == binding.RoleID {
+			found = true
+		}
+	}
+	if !found {
+		writeError(w, r, http.StatusBadRequest, "unknown role")
+		return
+	}
+
+	binding.ID = "binding-" + newToken()[:8]
+	s.fixtures.RoleBindings = append(s.fixtures.RoleBindings, binding)
+
+	writeJSON(w, http.StatusCreated, binding)
+}
+
+func (s *Server) deleteRoleBinding(w http.ResponseWriter, r *http.Request, bindingID string) {
+	for i, binding := range s.fixtures.RoleBindings {
+		if binding.ID == bindingID {
+			s.fixtures.RoleBindings = append(s.fixtures.RoleBindings[:i:i], s.fixtures.RoleBindings[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	writeError(w, r, http.StatusNotFound, "role binding not found")
+}
+
+func (s *Server) policyETag(policyID string) string {
+	return strconv.Quote(strconv.Itoa(s.versions[policyID]))
+}
+
+func (s *Server) getApplication(w http.ResponseWriter, r *http.Request, applicationID string) {
+	for _, a := range s.fixtures.Applications {
+		if a.ID == applicationID {
+			writeJSON(w, http.StatusOK, a)
+			return
+		}
+	}
+
+	writeError(w, r, http.StatusNotFound, "application not found")
+}
+
+// match reports whether the path parts match pattern, where "*" matches any single part.
+func match(parts []string, pattern ...string) bool {
+	if len(parts) != len(pattern) {
+		return false
+	}
+
+	for i, p := range pattern {
+		if p != "*" && p != parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func pageSize(r *http.Request, param string) int {
+	size, err := strconv.Atoi(r.URL.Query().Get(param))
+	if err != nil || size <= 0 {
+		return sac.DefaultPageSize
+	}
+	return size
+}
+
+// writeOffsetPage writes a page of items using pageOffset/perPage pagination. The offset token is the
+// index of the first item of the page.
+func writeOffsetPage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	size := pageSize(r, "perPage")
+
+	start := 0
+	if offset := r.URL.Query().Get("pageOffset"); offset != "" {
+		var err error
+		start, err = strconv.Atoi(offset)
+		if err != nil || start < 0 || start > len(items) {
+			writeError(w, r, http.StatusBadRequest, "invalid pageOffset")
+			return
+		}
+	}
+
+	end := start + size
+	if end > len(items) {
+		end = len(items)
+	}
+
+	pd := sac.PaginationData{
+		First:            start == 0,
+		Last:             end >= len(items),
+		Size:             size,
+		PerPage:          size,
+		TotalElements:    len(items),
+		NumberOfElements: end - start,
+	}
+	if !pd.Last {
+		pd.NextPage = strconv.Itoa(end)
+	}
+
+	writePage(w, items[start:end], pd)
+}
+
+// writeNumberedPage writes a page of items using zero-based page/size pagination.
+func writeNumberedPage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	size := pageSize(r, "size")
+
+	number, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || number < 0 {
+		number = 0
+	}
+
+	start := number * size
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + size
+	if end > len(items) {
+		end = len(items)
+	}
+
+	totalPages := (len(items) + size - 1) / size
+	pd := sac.PaginationData{
+		First:            number == 0,
+		Last:             number >= totalPages-1,
+		Size:             size,
+		TotalElements:    len(items),
+		TotalPages:       totalPages,
+		Number:           number,
+		NumberOfElements: end - start,
+	}
+
+	writePage(w, items[start:end], pd)
+}
+
+func writePage[T any](w http.ResponseWriter, items []T, pd sac.PaginationData) {
+	if items == nil {
+		items = []T{}
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Content []T `json:"content"`
+		sac.PaginationData
+	}{
+		Content:        items,
+		PaginationData: pd,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.Header().Set("X-Request-Id", newToken()[:16])
+	writeJSON(w, status, sac.ErrorResponse{
+		Status:  status,
+		Error:   http.StatusText(status),
+		Message: message,
+		Path:    r.URL.Path,
+	})
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("sactest: failed to generate token: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,207 @@
+package sactest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac"
+	"github.com/conductorone/baton-broadcom-sac/pkg/sac/sactest"
+)
+
+// testFixtures returns more users, members and policies than fit on a page of size 2.
+func testFixtures() sactest.Fixtures {
+	users := []sac.User{
+		{ID: "user-1", Username: "one", IdentityProviderID: sactest.LocalIdentityProviderID},
+		{ID: "user-2", Username: "two", IdentityProviderID: sactest.LocalIdentityProviderID},
+		{ID: "user-3", Username: "three", IdentityProviderID: sactest.LocalIdentityProviderID},
+	}
+
+	return sactest.Fixtures{
+		IdentityProviders: []sac.IdentityProvider{
+			{ID: sactest.LocalIdentityProviderID, Name: "Local", Provider: "Local"},
+		},
+		Users: map[string][]sac.User{
+			sactest.LocalIdentityProviderID: users,
+		},
+		Groups: map[string][]sac.Group{
+			sactest.LocalIdentityProviderID: {
+				{ID: "group-all", Name: "All", IdentityProviderID: sactest.LocalIdentityProviderID},
+			},
+		},
+		GroupMembers: map[string][]string{
+			"group-all": {"user-1", "user-2", "user-3"},
+		},
+		Policies: []sac.Policy{
+			{ID: "policy-1", Name: "One"},
+			{ID: "policy-2", Name: "Two"},
+			{ID: "policy-3", Name: "Three"},
+		},
+	}
+}
+
+// testRetryPolicy retries quickly, so tests of throttling do not wait for the default backoff.
+var testRetryPolicy = sac.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     10 * time.Millisecond,
+}
+
+func newTestServer(t *testing.T, opts ...sactest.Option) *sactest.Server {
+	t.Helper()
+
+	srv := sactest.NewServer(testFixtures(), opts...)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestServerPagesByOffset(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := srv.NewClient()
+
+	users, err := client.UsersPager(sactest.LocalIdentityProviderID, 2).All(ctx)
+	if err != nil {
+		t.Fatalf("listing users: %v", err)
+	}
+	if len(users) != 3 || users[2].ID != "user-3" {
+		t.Fatalf("got users %+v, want user-1, user-2 and user-3", users)
+	}
+	if got := srv.Requests(http.MethodGet, "/v2/identities/"+sactest.LocalIdentityProviderID+"/users"); got != 2 {
+		t.Errorf("got %d user requests, want 2", got)
+	}
+
+	members, err := client.GroupMembersPager(sactest.LocalIdentityProviderID, "group-all", 2).All(ctx)
+	if err != nil {
+		t.Fatalf("listing group members: %v", err)
+	}
+	if len(members) != 3 || members[0].Username != "one" {
+		t.Fatalf("got members %+v, want the three users", members)
+	}
+}
+
+func TestServerPagesByNumber(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := srv.NewClient()
+
+	policies, pd, err := client.ListPolicies(ctx, 0)
+	if err != nil {
+		t.Fatalf("listing policies: %v", err)
+	}
+	if len(policies) != 3 {
+		t.Fatalf("got %d policies on the first page, want 3", len(policies))
+	}
+	if !pd.Last || pd.TotalElements != 3 {
+		t.Errorf("got pagination %+v, want the last page of 3 elements", pd)
+	}
+
+	policies, err = client.PoliciesPager(2).All(ctx)
+	if err != nil {
+		t.Fatalf("paging policies: %v", err)
+	}
+	if len(policies) != 3 || policies[2].ID != "policy-3" {
+		t.Fatalf("got policies %+v, want policy-1, policy-2 and policy-3", policies)
+	}
+	if got := srv.Requests(http.MethodGet, "/v2/policies"); got != 3 {
+		t.Errorf("got %d policy requests, want 3", got)
+	}
+}
+
+func TestServerRejectsUnknownCredentials(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t, sactest.WithCredentials("id", "secret"))
+
+	tokenSource := sac.NewTokenSource(srv.Client(), sac.TokenURL(srv.URL), sactest.ClientID, sactest.ClientSecret)
+	if _, err := tokenSource.Token(ctx); err == nil {
+		t.Fatal("got a token for unknown credentials")
+	}
+
+	if _, err := srv.NewClient().GetPolicy(ctx, "policy-1"); err != nil {
+		t.Fatalf("getting a policy with the configured credentials: %v", err)
+	}
+}
+
+func TestServerExpiresTokens(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := srv.NewClient()
+
+	if _, err := client.GetPolicy(ctx, "policy-1"); err != nil {
+		t.Fatalf("getting policy: %v", err)
+	}
+
+	srv.ExpireTokens()
+
+	if _, err := client.GetPolicy(ctx, "policy-2"); err != nil {
+		t.Fatalf("getting policy after the tokens expired: %v", err)
+	}
+	if got := srv.Requests(http.MethodPost, "/v1/oauth/token"); got != 2 {
+		t.Errorf("got %d token requests, want 2", got)
+	}
+}
+
+func TestServerInjectsFaults(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	srv.Inject(sactest.Fault{Method: http.MethodGet, Path: "/v2/policies/policy-1", Status: http.StatusNotFound, Times: 1})
+	client := srv.NewClient()
+
+	if _, err := client.GetPolicy(ctx, "policy-1"); !sac.IsNotFound(err) {
+		t.Fatalf("got error %v, want not found", err)
+	}
+
+	policy, err := client.GetPolicy(ctx, "policy-1")
+	if err != nil {
+		t.Fatalf("getting policy once the fault is used up: %v", err)
+	}
+	if policy.Name != "One" {
+		t.Errorf("got policy %+v, want policy-1", policy)
+	}
+}
+
+func TestServerThrottles(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	srv.Throttle("/v2/policies", 2, 0)
+
+	_, err := srv.NewClient(sac.WithRetryPolicy(sac.RetryPolicy{MaxAttempts: 1})).GetPolicy(ctx, "policy-1")
+	if !sac.IsRateLimited(err) {
+		t.Fatalf("got error %v, want rate limited", err)
+	}
+
+	if _, err := srv.NewClient(sac.WithRetryPolicy(testRetryPolicy)).GetPolicy(ctx, "policy-1"); err != nil {
+		t.Fatalf("getting policy with retries: %v", err)
+	}
+	if got := srv.Requests(http.MethodGet, "/v2/policies/policy-1"); got != 3 {
+		t.Errorf("got %d policy requests, want 3", got)
+	}
+}
+
+func TestServerDelaysResponses(t *testing.T) {
+	srv := newTestServer(t, sactest.WithLatency(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := srv.NewClient(sac.WithRetryPolicy(sac.RetryPolicy{MaxAttempts: 1})).GetPolicy(ctx, "policy-1"); err == nil {
+		t.Fatal("got a response before the configured latency")
+	}
+}
+
+func TestServerServesUpdatedFixtures(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	srv.Update(func(f *sactest.Fixtures) {
+		f.Policies[0].Name = "Renamed"
+	})
+
+	policy, err := srv.NewClient().GetPolicy(ctx, "policy-1")
+	if err != nil {
+		t.Fatalf("getting policy: %v", err)
+	}
+	if policy.Name != "Renamed" {
+		t.Errorf("got policy name %q, want Renamed", policy.Name)
+	}
+}